@@ -0,0 +1,253 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/no-mole/neptune/application"
+	"github.com/no-mole/neptune/cron"
+	"github.com/no-mole/neptune/grpc_service"
+	"github.com/no-mole/neptune/health"
+	"github.com/no-mole/neptune/json"
+	"github.com/no-mole/neptune/logger"
+)
+
+type Option func(p *Plugin)
+
+// WithCron expose entries of cron manager by name
+func WithCron(name string, m *cron.Manger) Option {
+	return func(p *Plugin) {
+		p.crons[name] = m
+	}
+}
+
+// NewPlugin admin plugin serving runtime diagnostics of app on a separate endpoint
+func NewPlugin(app *application.App, opts ...Option) *Plugin {
+	conf := &Conf{}
+	p := &Plugin{
+		ConfigPlugin: application.NewConfigPlugin("admin", &application.PluginConfigOptions{
+			ConfigName: "app.yaml",
+			ConfigType: "yaml",
+		}, conf),
+		app:   app,
+		conf:  conf,
+		crons: map[string]*cron.Manger{},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+type Plugin struct {
+	*application.ConfigPlugin[Conf] `yaml:"-" json:"-"`
+
+	app      *application.App
+	conf     *Conf
+	crons    map[string]*cron.Manger
+	server   *http.Server
+	listener net.Listener
+}
+
+type Conf struct {
+	Endpoint string `json:"admin-endpoint" yaml:"admin-endpoint" flag:"admin-endpoint" default:"127.0.0.1:6060" usage:"admin server endpoint serving pprof and diagnostics,default is [127.0.0.1:6060]" validate:"required"`
+}
+
+func (p *Plugin) Init(ctx context.Context) (err error) {
+	logger.Info(ctx, "admin server init", logger.WithField("adminEndpoint", p.conf.Endpoint))
+	p.listener, err = net.Listen("tcp", p.conf.Endpoint)
+	if err != nil {
+		return err
+	}
+	p.server = &http.Server{Handler: p.Handler()}
+	return nil
+}
+
+func (p *Plugin) Run(ctx context.Context) error {
+	logger.Info(ctx, "admin server started", logger.WithField("adminEndpoint", p.listener.Addr().String()))
+	err := p.server.Serve(p.listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Stop shutdown admin server
+func (p *Plugin) Stop(ctx context.Context) error {
+	if p.server == nil {
+		return nil
+	}
+	return p.server.Shutdown(ctx)
+}
+
+// Restartable the listener is closed when Serve returns,Run can not be called again
+func (p *Plugin) Restartable() bool {
+	return false
+}
+
+// Addr listening address,nil before Init
+func (p *Plugin) Addr() net.Addr {
+	if p.listener == nil {
+		return nil
+	}
+	return p.listener.Addr()
+}
+
+// Handler routes of admin server
+func (p *Plugin) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	mux.Handle(health.LivenessPath, health.LivenessHandler())
+	mux.Handle(health.ReadinessPath, health.ReadinessHandler())
+
+	mux.HandleFunc("/plugins", p.plugins)
+	mux.HandleFunc("/config", p.config)
+	mux.HandleFunc("/grpc/services", p.grpcServices)
+	mux.HandleFunc("/log-level", p.logLevel)
+	mux.HandleFunc("/cron", p.cronEntries)
+	return mux
+}
+
+type PluginInfo struct {
+	Name         string       `json:"name"`
+	State        health.State `json:"state"`
+	Dependencies []string     `json:"dependencies"`
+}
+
+func (p *Plugin) plugins(w http.ResponseWriter, r *http.Request) {
+	list := []*PluginInfo{}
+	for _, plg := range p.app.Plugins() {
+		list = append(list, &PluginInfo{
+			Name:         plg.Name(),
+			State:        p.app.PluginState(plg.Name()),
+			Dependencies: p.app.PluginDependencies(plg.Name()),
+		})
+	}
+	writeJson(w, http.StatusOK, list)
+}
+
+func (p *Plugin) config(w http.ResponseWriter, r *http.Request) {
+	docs := map[string]any{}
+	for _, plg := range p.app.Plugins() {
+		doc, err := application.ConfigDocument(plg)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		docs[plg.Name()] = application.RedactConfig(doc)
+	}
+	writeJson(w, http.StatusOK, docs)
+}
+
+func (p *Plugin) grpcServices(w http.ResponseWriter, r *http.Request) {
+	services := grpc_service.Registered()
+	if services == nil {
+		services = []*grpc_service.RegisteredService{}
+	}
+	writeJson(w, http.StatusOK, services)
+}
+
+type LogLevel struct {
+	Level string `json:"level"`
+}
+
+// levelGetter is implemented by loggers exposing their current level,such as the logger created by logger.NewLogger
+type levelGetter interface {
+	GetLevel() logger.Level
+}
+
+// logLevel GET current level,PUT {"level":"debug"} or ?level=debug change level at runtime
+func (p *Plugin) logLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		l, ok := logger.GetLogger().(levelGetter)
+		if !ok {
+			http.Error(w, "level of current logger is unknown", http.StatusNotImplemented)
+			return
+		}
+		writeJson(w, http.StatusOK, &LogLevel{Level: l.GetLevel().String()})
+	case http.MethodPut, http.MethodPost:
+		name := r.URL.Query().Get("level")
+		if name == "" {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			req := &LogLevel{}
+			err = json.Unmarshal(body, req)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			name = req.Level
+		}
+		level := logger.GetLevelByName(strings.ToLower(name))
+		if level.String() != strings.ToLower(name) {
+			http.Error(w, "unknown log level:"+name, http.StatusBadRequest)
+			return
+		}
+		logger.SetLevel(level)
+		logger.Info(r.Context(), "admin", logger.WithField("msg", "log level changed"), logger.WithField("level", level.String()))
+		writeJson(w, http.StatusOK, &LogLevel{Level: level.String()})
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+type CronEntry struct {
+	Manager string    `json:"manager"`
+	ID      int       `json:"id"`
+	Key     string    `json:"key,omitempty"`
+	Spec    string    `json:"spec,omitempty"`
+	Next    time.Time `json:"next"`
+	Prev    time.Time `json:"prev"`
+}
+
+func (p *Plugin) cronEntries(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(p.crons))
+	for name := range p.crons {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	list := []*CronEntry{}
+	for _, name := range names {
+		for _, entry := range p.crons[name].Entries() {
+			item := &CronEntry{
+				Manager: name,
+				ID:      int(entry.ID),
+				Next:    entry.Next,
+				Prev:    entry.Prev,
+			}
+			if job, ok := entry.Job.(cron.Job); ok {
+				item.Key = job.Key()
+				item.Spec = job.Spec()
+			}
+			list = append(list, item)
+		}
+	}
+	writeJson(w, http.StatusOK, list)
+}
+
+func writeJson(w http.ResponseWriter, code int, data any) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	_, _ = w.Write(body)
+}
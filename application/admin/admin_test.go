@@ -0,0 +1,133 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/no-mole/neptune/application"
+	"github.com/no-mole/neptune/application/apptest"
+	"github.com/no-mole/neptune/cron"
+	"github.com/no-mole/neptune/health"
+	"github.com/no-mole/neptune/json"
+	"github.com/no-mole/neptune/logger"
+	"go.uber.org/zap"
+)
+
+type dbConf struct {
+	Host     string `yaml:"host" flag:"db-host"`
+	Password string `yaml:"password" flag:"db-password"`
+}
+
+type dbPlugin struct {
+	*application.ConfigPlugin[dbConf]
+}
+
+func (d *dbPlugin) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+type testJob struct{}
+
+func (testJob) Key() string  { return "cleanup" }
+func (testJob) Spec() string { return "@every 1h" }
+func (testJob) Run()         {}
+
+// noLevelLogger hides GetLevel of the embedded logger
+type noLevelLogger struct {
+	logger.Logger
+}
+
+func serve(t *testing.T, h http.Handler, method, target string, v any) int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	if v != nil && w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return w.Code
+}
+
+func TestAdmin(t *testing.T) {
+	//app replaces the global logger,create it before the cron goroutine logging
+	app := apptest.New(t)
+	manager := cron.New()
+	manager.Start()
+	defer manager.Stop()
+	manager.Add(testJob{})
+	manager.Remove("") //jobs are added by the manager loop,wait the add processed
+
+	admin := NewPlugin(app.App, WithCron("jobs", manager))
+	app.Use(admin, &dbPlugin{ConfigPlugin: application.NewConfigPlugin("database", &application.PluginConfigOptions{}, &dbConf{})})
+	app.Config("database", "host: db\npassword: s3cr3t\n")
+	app.Start()
+	h := admin.Handler()
+
+	var plugins []*PluginInfo
+	if code := serve(t, h, http.MethodGet, "/plugins", &plugins); code != http.StatusOK {
+		t.Fatalf("plugins status %d", code)
+	}
+	if len(plugins) != 2 {
+		t.Fatalf("unexpected plugins %+v", plugins)
+	}
+	for _, plg := range plugins {
+		if plg.State != health.StateRunning {
+			t.Fatalf("plugin [%s] should be running,got %s", plg.Name, plg.State)
+		}
+	}
+
+	docs := map[string]map[string]any{}
+	if code := serve(t, h, http.MethodGet, "/config", &docs); code != http.StatusOK {
+		t.Fatalf("config status %d", code)
+	}
+	if docs["database"]["host"] != "db" || docs["database"]["password"] != "******" {
+		t.Fatalf("password should be redacted,got %v", docs["database"])
+	}
+
+	var entries []*CronEntry
+	if code := serve(t, h, http.MethodGet, "/cron", &entries); code != http.StatusOK {
+		t.Fatalf("cron status %d", code)
+	}
+	if len(entries) != 1 || entries[0].Manager != "jobs" || entries[0].Key != "cleanup" || entries[0].Spec != "@every 1h" {
+		t.Fatalf("unexpected cron entries %+v", entries)
+	}
+}
+
+func TestLogLevel(t *testing.T) {
+	defer func(l logger.Logger) { logger.SetLogger(l) }(logger.GetLogger())
+	logger.SetLogger(logger.NewLogger(context.Background(), zap.NewNop()))
+	h := (&Plugin{}).Handler()
+
+	level := &LogLevel{}
+	if code := serve(t, h, http.MethodPut, "/log-level?level=warn", level); code != http.StatusOK || level.Level != "warn" {
+		t.Fatalf("unexpected change level response %d %s", code, level.Level)
+	}
+	if code := serve(t, h, http.MethodGet, "/log-level", level); code != http.StatusOK || level.Level != "warn" {
+		t.Fatalf("unexpected level %d %s", code, level.Level)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/log-level", strings.NewReader(`{"level":"debug"}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("change level by body status %d", w.Code)
+	}
+	if code := serve(t, h, http.MethodGet, "/log-level", level); code != http.StatusOK || level.Level != "debug" {
+		t.Fatalf("unexpected level %d %s", code, level.Level)
+	}
+	if code := serve(t, h, http.MethodPut, "/log-level?level=verbose", nil); code != http.StatusBadRequest {
+		t.Fatalf("unknown level should be rejected,got %d", code)
+	}
+	if code := serve(t, h, http.MethodDelete, "/log-level", nil); code != http.StatusMethodNotAllowed {
+		t.Fatalf("unexpected status %d", code)
+	}
+
+	logger.SetLogger(noLevelLogger{Logger: logger.GetLogger()})
+	if code := serve(t, h, http.MethodGet, "/log-level", nil); code != http.StatusNotImplemented {
+		t.Fatalf("logger without GetLevel should be 501,got %d", code)
+	}
+}
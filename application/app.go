@@ -2,12 +2,16 @@ package application
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
+	"github.com/no-mole/neptune/health"
 	"github.com/no-mole/neptune/logger"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -28,6 +32,8 @@ var (
 	AppModeDev  = "dev"
 
 	DefaultEnvPrefix = "neptune"
+
+	DefaultShutdownTimeout = 30 * time.Second
 )
 
 type App struct {
@@ -36,79 +42,82 @@ type App struct {
 	ctx    context.Context
 	cancel context.CancelFunc
 
-	plugins []Plugin
-	hooks   []HookFunc
+	plugins  []Plugin
+	hooks    []HookFunc
+	commands map[*cobra.Command]*Command
+	//supervisions supervision policies of plugins by name,plugins without policy are fail-fast
+	supervisions map[string]Supervision
+
+	//ordered plugins sorted by dependencies,dependencies are the used dependency names of each plugin
+	ordered      []Plugin
+	dependencies map[string][]string
+
+	states   map[string]health.State
+	statesMu sync.RWMutex
+
+	//configs config bodies of plugins used instead of config files
+	configs map[string][]byte
+	//ready closed when all plugins are ready
+	ready chan struct{}
 
 	Mode      string //app run mode,default is [prod]
 	LogLevel  string //slog level,default is info,[trace|debug|notice|info|warn|error|fatal]
 	EnvPrefix string //app global env prefix,default is neptune
+
+	ShutdownTimeout time.Duration //max duration to wait for plugins stop,default is 30s
 }
 
 func New(ctx context.Context) *App {
 	newCtx, cancel := context.WithCancel(ctx)
 
 	app := &App{
-		ctx:       newCtx,
-		cancel:    cancel,
-		EnvPrefix: DefaultEnvPrefix,
+		ctx:             newCtx,
+		cancel:          cancel,
+		EnvPrefix:       DefaultEnvPrefix,
+		ShutdownTimeout: DefaultShutdownTimeout,
+		states:          map[string]health.State{},
+		configs:         map[string][]byte{},
+		commands:        map[*cobra.Command]*Command{},
+		supervisions:    map[string]Supervision{},
+		ready:           make(chan struct{}),
 	}
 
 	app.command = &cobra.Command{
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			var err error
-			logger.Info(app.ctx, "application pre run", logger.WithField("mode", app.Mode), logger.WithField("envPrefix", app.EnvPrefix))
+			logger.Info(app.ctx, "application pre run", logger.WithField("mode", app.Mode), logger.WithField("envPrefix", app.EnvPrefix), logger.WithField("command", cmd.Name()))
+			plugins, err := app.commandPlugins(cmd)
+			if err != nil {
+				return err
+			}
+			app.ordered, app.dependencies, err = sortPlugins(plugins)
+			if err != nil {
+				return err
+			}
 			//plugin init
-			for _, plg := range app.plugins {
-				err = app.pluginConfigInit(plg)
-				if err != nil {
-					return err
-				}
-				err = app.pluginEnvBind(plg)
+			for _, plg := range app.ordered {
+				err = app.configurePlugin(plg)
 				if err != nil {
 					return err
 				}
 				err = plg.Init(app.ctx)
 				if err != nil {
+					app.setState(plg.Name(), health.StateFailed)
 					return err
 				}
+				app.setState(plg.Name(), health.StateInitialized)
 			}
 			return nil
 		},
-		RunE: func(cmd *cobra.Command, args []string) (err error) {
-			//run hooks
-			for _, hook := range app.hooks {
-				err = hook(app.ctx)
-				if err != nil {
-					logger.Error(app.ctx, "run hook err", err)
-					return err
-				}
-			}
-			plgNames := make([]string, 0, len(app.plugins))
-			for _, plg := range app.plugins {
-				plgNames = append(plgNames, plg.Name())
-			}
-			logger.Info(app.ctx, "application will started", logger.WithField("mode", app.Mode), logger.WithField("envPrefix", app.EnvPrefix), logger.WithField("plugins", plgNames))
-			eg, _ := errgroup.WithContext(app.ctx)
-			for _, plg := range app.plugins {
-				eg.Go(
-					func(p Plugin) func() error {
-						return func() error {
-							return p.Run(app.ctx)
-						}
-					}(plg))
-			}
-			defer func() {
-				if err != nil {
-					logger.Error(app.ctx, "app shutdown", err)
-				} else {
-					logger.Info(app.ctx, "app shutdown")
-				}
-			}()
-			return eg.Wait()
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return app.run(app.hooks, nil)
 		},
 	}
 	app.command.PersistentFlags().StringVar(&app.Mode, "mode", AppModeDev, "app run mode for [prod|grey|test|dev],default is dev")
 	app.command.PersistentFlags().StringVar(&app.LogLevel, "log-level", logger.LevelInfo.String(), "slog level,default is info,[debug|info|warn|error]")
+	app.command.PersistentFlags().DurationVar(&app.ShutdownTimeout, "shutdown-timeout", DefaultShutdownTimeout, "max duration to wait for plugins to stop,default is 30s")
+
+	app.command.AddCommand(app.newConfigCommand())
 
 	//init app config for flags and env
 	app.initConfig()
@@ -125,13 +134,35 @@ func New(ctx context.Context) *App {
 }
 
 func (app *App) Run() error {
-	app.listenSigns()
+	stop := app.listenSigns()
+	defer stop()
+	return app.Execute(os.Args[1:]...)
+}
+
+// Execute run app with args instead of os.Args and without listening signals,
+// the app is stopped by canceling the context or calling Cancel
+func (app *App) Execute(args ...string) error {
 	for _, plg := range app.plugins {
 		app.command.PersistentFlags().AddFlagSet(plg.Flags())
 	}
+	if args == nil {
+		//cobra uses os.Args when args is nil
+		args = []string{}
+	}
+	app.command.SetArgs(args)
 	return app.command.Execute()
 }
 
+// SetPluginConfig config body of plugin used instead of its config files,flags and env still apply
+func (app *App) SetPluginConfig(name string, body []byte) {
+	app.configs[name] = body
+}
+
+// Ready closed when all plugins are ready
+func (app *App) Ready() <-chan struct{} {
+	return app.ready
+}
+
 func (app *App) Cancel(msg string, args ...any) {
 	app.cancel()
 	logger.Info(app.ctx, fmt.Sprintf(msg, args...))
@@ -146,6 +177,183 @@ func (app *App) Hook(hooks ...HookFunc) {
 	app.hooks = append(app.hooks, hooks...)
 }
 
+// run hooks,then run plugins until the app is canceled,
+// or run job after plugins are ready and cancel the app when it returns
+func (app *App) run(hooks []HookFunc, job func(ctx context.Context) error) (err error) {
+	for _, hook := range hooks {
+		err = hook(app.ctx)
+		if err != nil {
+			logger.Error(app.ctx, "run hook err", err)
+			return err
+		}
+	}
+	plgNames := make([]string, 0, len(app.ordered))
+	for _, plg := range app.ordered {
+		plgNames = append(plgNames, plg.Name())
+	}
+	logger.Info(app.ctx, "application will started", logger.WithField("mode", app.Mode), logger.WithField("envPrefix", app.EnvPrefix), logger.WithField("plugins", plgNames))
+	defer func() {
+		if err != nil {
+			logger.Error(app.ctx, "app shutdown", err)
+		} else {
+			logger.Info(app.ctx, "app shutdown")
+		}
+	}()
+	return app.runPlugins(job)
+}
+
+// runPlugins run all plugins until the app is canceled, a plugin returns an error or all plugins returned,
+// then stop plugins in reverse order within ShutdownTimeout.
+// Each plugin runs after all of its dependencies are ready.
+// job runs after all plugins are ready and cancels the app when it returns
+func (app *App) runPlugins(job func(ctx context.Context) error) error {
+	eg, runCtx := errgroup.WithContext(app.ctx)
+	//waiters of plugins readiness,joined before return so they do not outlive the app
+	var waiters sync.WaitGroup
+	defer waiters.Wait()
+	readyChs := make(map[string]chan struct{}, len(app.ordered))
+	for _, plg := range app.ordered {
+		readyChs[plg.Name()] = make(chan struct{})
+	}
+	go func() {
+		for _, ch := range readyChs {
+			select {
+			case <-ch:
+			case <-runCtx.Done():
+				return
+			}
+		}
+		close(app.ready)
+	}()
+	for _, plg := range app.ordered {
+		eg.Go(
+			func(p Plugin) func() error {
+				return func() error {
+					for _, dep := range app.dependencies[p.Name()] {
+						select {
+						case <-readyChs[dep]:
+						case <-runCtx.Done():
+							return nil
+						}
+					}
+					waiters.Add(1)
+					go func() {
+						defer waiters.Done()
+						app.waitReady(runCtx, p, readyChs[p.Name()])
+					}()
+					err := app.supervise(runCtx, p)
+					if err != nil {
+						app.setState(p.Name(), health.StateFailed)
+					}
+					return err
+				}
+			}(plg))
+	}
+	if job != nil {
+		eg.Go(func() error {
+			select {
+			case <-app.ready:
+			case <-runCtx.Done():
+				return nil
+			}
+			defer app.Cancel("application job finished")
+			return job(runCtx)
+		})
+	}
+	waitCh := make(chan error, 1)
+	go func() {
+		waitCh <- eg.Wait()
+	}()
+
+	<-runCtx.Done()
+	logger.Info(app.ctx, "application stopping", logger.WithField("shutdownTimeout", app.ShutdownTimeout.String()))
+	for _, plg := range app.ordered {
+		app.setState(plg.Name(), health.StateStopping)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), app.ShutdownTimeout)
+	defer cancel()
+	stopErr := app.stopPlugins(shutdownCtx)
+
+	var runErr error
+	select {
+	case runErr = <-waitCh:
+	case <-shutdownCtx.Done():
+		runErr = fmt.Errorf("wait plugins stop: %w", shutdownCtx.Err())
+	}
+	return errors.Join(runErr, stopErr)
+}
+
+// waitReady close ready when plugin is ready
+func (app *App) waitReady(ctx context.Context, plg Plugin, ready chan struct{}) {
+	if r, ok := plg.(Readier); ok {
+		select {
+		case <-r.Ready():
+		case <-ctx.Done():
+			return
+		}
+	}
+	logger.Debug(app.ctx, "plugin ready", logger.WithField("pluginName", plg.Name()))
+	app.setState(plg.Name(), health.StateRunning)
+	close(ready)
+}
+
+// stopPlugins call Stop of plugins implemented Stopper in reverse order
+func (app *App) stopPlugins(ctx context.Context) error {
+	var errs []error
+	for i := len(app.ordered) - 1; i >= 0; i-- {
+		stopper, ok := app.ordered[i].(Stopper)
+		if !ok {
+			app.setState(app.ordered[i].Name(), health.StateStopped)
+			continue
+		}
+		opts := []zap.Field{logger.WithField("pluginName", app.ordered[i].Name())}
+		logger.Info(app.ctx, "stop plugin", opts...)
+		err := stopper.Stop(ctx)
+		if err != nil {
+			logger.Error(app.ctx, "stop plugin error", err, opts...)
+			errs = append(errs, fmt.Errorf("stop plugin [%s]: %w", app.ordered[i].Name(), err))
+		}
+		app.setState(app.ordered[i].Name(), health.StateStopped)
+	}
+	return errors.Join(errs...)
+}
+
+// setState record plugin state and report it to health,a failed plugin keeps failed,
+// an exited plugin keeps exited until stopped
+func (app *App) setState(name string, state health.State) {
+	app.statesMu.Lock()
+	current := app.states[name]
+	if current == health.StateFailed || (current == health.StateExited && state != health.StateStopped) {
+		app.statesMu.Unlock()
+		return
+	}
+	app.states[name] = state
+	app.statesMu.Unlock()
+	//health listeners may be slow,PluginState must not wait on them
+	health.SetState(name, state)
+}
+
+// Plugins used plugins,sorted by dependencies once the app started
+func (app *App) Plugins() []Plugin {
+	if app.ordered != nil {
+		return append([]Plugin{}, app.ordered...)
+	}
+	return append([]Plugin{}, app.plugins...)
+}
+
+// PluginDependencies names of used plugins that the plugin depends on
+func (app *App) PluginDependencies(name string) []string {
+	return app.dependencies[name]
+}
+
+// PluginState current lifecycle state of plugin
+func (app *App) PluginState(name string) health.State {
+	app.statesMu.RLock()
+	defer app.statesMu.RUnlock()
+	return app.states[name]
+}
+
 func (app *App) initConfig() {
 	v := viper.New()
 	v.AddConfigPath(".")
@@ -161,63 +369,140 @@ func (app *App) initConfig() {
 	BindFlags(app.ctx, "app", app.command.PersistentFlags(), v)
 }
 
-func (app *App) listenSigns() {
+// listenSigns SIGINT/SIGTERM cancel the app for graceful shutdown,a second one force exit,
+// SIGHUP reload plugins config.returns a func to stop listening
+func (app *App) listenSigns() func() {
+	signs := make(chan os.Signal, 2)
+	done := make(chan struct{})
+	signal.Notify(signs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 	go func() {
-		signs := make(chan os.Signal, 1)
-		signal.Notify(signs, syscall.SIGKILL, syscall.SIGTERM)
-		select {
-		case <-app.ctx.Done():
-		case sign := <-signs:
-			app.Cancel("receive sig:[%s],app canceled", sign.String())
+		canceled := false
+		for {
+			select {
+			case <-done:
+				return
+			case sign := <-signs:
+				if sign == syscall.SIGHUP {
+					app.reload()
+					continue
+				}
+				if canceled {
+					logger.Warning(app.ctx, fmt.Sprintf("receive sig:[%s] again,app force exit", sign.String()), nil)
+					os.Exit(1)
+				}
+				canceled = true
+				app.Cancel("receive sig:[%s],app canceled", sign.String())
+			}
 		}
-		signal.Stop(signs)
-		close(signs)
 	}()
+	return func() {
+		signal.Stop(signs)
+		close(done)
+	}
 }
 
-func (app *App) pluginConfigInit(plg Plugin) error {
-	if plg.ConfigOptions().ConfigName == "" && plg.ConfigOptions().ConfigFile == "" {
-		return nil
+// reload re-read config files of plugins and call Reload of plugins implemented Reloader
+func (app *App) reload() {
+	logger.Info(app.ctx, "application reload config")
+	for _, plg := range app.ordered {
+		reloader, ok := plg.(Reloader)
+		if !ok {
+			continue
+		}
+		opts := []zap.Field{logger.WithField("pluginName", plg.Name())}
+		body, found, err := app.readPluginConfig(plg)
+		if err != nil {
+			logger.Error(app.ctx, "reload plugin error", err, opts...)
+			continue
+		}
+		if !found {
+			continue
+		}
+		err = reloader.Reload(app.ctx, body)
+		if err != nil {
+			logger.Error(app.ctx, "reload plugin error", err, opts...)
+			continue
+		}
+		logger.Info(app.ctx, "plugin reloaded", opts...)
 	}
+}
 
-	v := viper.New()
-	v.AddConfigPath(".")
-	v.AddConfigPath("./config")
-	v.AddConfigPath(fmt.Sprintf("./config/%s", app.Mode))
+// configurePlugin apply config files and env to plugin,then validate the config
+func (app *App) configurePlugin(plg Plugin) error {
+	err := app.pluginConfigInit(plg)
+	if err != nil {
+		return err
+	}
+	err = app.pluginEnvBind(plg)
+	if err != nil {
+		return err
+	}
+	if v, ok := plg.(ConfigValidator); ok {
+		err = v.ValidateConfig()
+		if err != nil {
+			return fmt.Errorf("plugin [%s] config invalid: %w", plg.Name(), err)
+		}
+	}
+	err = app.validateSupervision(plg)
+	if err != nil {
+		return fmt.Errorf("plugin [%s] config invalid: %w", plg.Name(), err)
+	}
+	return nil
+}
+
+func (app *App) pluginConfigInit(plg Plugin) error {
+	body, found, err := app.readPluginConfig(plg)
+	if err != nil || !found {
+		return err
+	}
+	err = plg.Config(app.ctx, body)
+	if err != nil {
+		logger.Error(app.ctx, "init plugin error", err, logger.WithField("pluginName", plg.Name()))
+	}
+	return err
+}
 
-	v.SetConfigFile(plg.ConfigOptions().ConfigFile)
-	v.SetConfigName(plg.ConfigOptions().ConfigName)
-	v.SetConfigType(plg.ConfigOptions().ConfigType)
+// readPluginConfig read config files of plugin,the base file and the mode overlay are deep merged,
+// ${ENV_VAR} in files are interpolated.found is false when plugin using no config file
+func (app *App) readPluginConfig(plg Plugin) (body []byte, found bool, err error) {
+	if body, ok := app.configs[plg.Name()]; ok {
+		return body, true, nil
+	}
+	if plg.ConfigOptions().ConfigName == "" && plg.ConfigOptions().ConfigFile == "" {
+		return nil, false, nil
+	}
 	opts := []zap.Field{logger.WithField("pluginName", plg.Name()), logger.WithField("pluginConfigOpts", plg.ConfigOptions())}
 
-	if err := v.ReadInConfig(); err != nil {
+	files := configFileLayers(plg.ConfigOptions(), app.Mode)
+	if len(files) == 0 {
 		logger.Info(app.ctx, "init plugin with no config file", opts...)
 		//using no config file
-		return nil
+		return nil, false, nil
 	}
-	opts = append(opts, logger.WithField("configFileUsed", v.ConfigFileUsed()))
-	logger.Info(app.ctx, "init plugin", opts...,
-	)
+	opts = append(opts, logger.WithField("configFilesUsed", files))
+	logger.Info(app.ctx, "init plugin", opts...)
 	//优先使用配置文件初始化
-	body, err := os.ReadFile(v.ConfigFileUsed())
-	if err != nil {
-		logger.Error(app.ctx, "init plugin error", err, opts...)
-		return err
-	}
-	err = plg.Config(app.ctx, body)
+	body, err = mergeConfigFiles(files, plg.ConfigOptions().ConfigType)
 	if err != nil {
 		logger.Error(app.ctx, "init plugin error", err, opts...)
+		return nil, false, err
 	}
-	return err
+	return body, true, nil
 }
 
-func (app *App) pluginEnvBind(plg Plugin) error {
-	v := viper.New()
-	//其次配置环境变量前缀 ${app_env_prefix}_${plugin_env_prefix}
+// pluginEnvPrefix ${app_env_prefix}_${plugin_env_prefix}
+func (app *App) pluginEnvPrefix(plg Plugin) string {
 	envPrefix := app.EnvPrefix
 	if plg.ConfigOptions().EnvPrefix != "" {
 		envPrefix += "_" + plg.ConfigOptions().EnvPrefix
 	}
+	return envPrefix
+}
+
+func (app *App) pluginEnvBind(plg Plugin) error {
+	v := viper.New()
+	//其次配置环境变量前缀 ${app_env_prefix}_${plugin_env_prefix}
+	envPrefix := app.pluginEnvPrefix(plg)
 	logger.Debug(app.ctx, "init plugin", logger.WithField("pluginName", plg.Name()), logger.WithField("pluginEnvPrefix", envPrefix))
 	v.SetEnvPrefix(envPrefix)
 	// Environment variables can't have dashes in them, so bind them to their equivalent
@@ -235,8 +520,8 @@ func BindFlags(ctx context.Context, groupName string, set *pflag.FlagSet, v *vip
 		// Determine the naming convention of the flags when represented in the config file
 		configName := f.Name
 		// Apply the viper config value to the flag when the flag is not set and viper has a value
-		// 优先环境变量
-		if v.IsSet(configName) {
+		// 优先环境变量,命令行指定的flag除外
+		if !f.Changed && v.IsSet(configName) {
 			val := v.Get(configName)
 			err := set.Set(f.Name, fmt.Sprintf("%v", val))
 			if err != nil {
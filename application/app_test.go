@@ -0,0 +1,71 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type stopRecorder struct {
+	mu      sync.Mutex
+	stopped []string
+}
+
+func (r *stopRecorder) record(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopped = append(r.stopped, name)
+}
+
+type stopperPlugin struct {
+	*dependPlugin
+	recorder *stopRecorder
+	slow     bool
+}
+
+func (s *stopperPlugin) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (s *stopperPlugin) Stop(ctx context.Context) error {
+	s.recorder.record(s.Name())
+	if s.slow {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return nil
+}
+
+func TestStopPlugins(t *testing.T) {
+	recorder := &stopRecorder{}
+	newStopper := func(name string, deps []string, slow bool) Plugin {
+		return &stopperPlugin{dependPlugin: newDependPlugin(name, deps).(*dependPlugin), recorder: recorder, slow: slow}
+	}
+	app := New(context.Background())
+	app.Use(
+		newStopper("server", []string{"cache"}, true),
+		newStopper("database", nil, false),
+		newStopper("cache", []string{"database"}, false),
+	)
+	go func() {
+		<-app.Ready()
+		app.Cancel("test stop")
+	}()
+	start := time.Now()
+	err := app.Execute("--shutdown-timeout", "50ms")
+	elapsed := time.Since(start)
+
+	if got := strings.Join(recorder.stopped, ","); got != "server,cache,database" {
+		t.Errorf("plugins should be stopped in reverse order,got %s", got)
+	}
+	if err == nil || !strings.Contains(err.Error(), "stop plugin [server]") || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expect shutdown timeout error of slow stopper,got %v", err)
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("shutdown should be bounded by shutdown timeout,took %s", elapsed)
+	}
+}
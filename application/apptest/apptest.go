@@ -0,0 +1,173 @@
+package apptest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/no-mole/neptune/application"
+	"github.com/no-mole/neptune/health"
+	"github.com/no-mole/neptune/utils"
+	"github.com/spf13/pflag"
+)
+
+var (
+	// EndpointFlags listening endpoint flags bound to a free local port when not given in args
+	EndpointFlags = []string{"grpc-endpoint", "http-endpoint", "ws-endpoint", "admin-endpoint", "metrics-endpoint"}
+
+	// StartTimeout max duration to wait for all plugins ready
+	StartTimeout = 10 * time.Second
+)
+
+// healthSyncer is implemented by plugins refreshing health statuses asynchronously,such as the grpc server
+type healthSyncer interface {
+	SyncHealth(ctx context.Context) error
+}
+
+// App in-process app for tests,plugins are configured by injected config instead of os.Args and config files
+type App struct {
+	*application.App
+
+	t      testing.TB
+	cancel context.CancelFunc
+
+	started  bool
+	exited   chan struct{}
+	err      error
+	stopOnce sync.Once
+}
+
+// New create app stopped when the test ends,
+// states and checkers of the app are removed from the global health registry then
+func New(t testing.TB) *App {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(health.Reset)
+	return &App{
+		App:    application.New(ctx),
+		t:      t,
+		cancel: cancel,
+		exited: make(chan struct{}),
+	}
+}
+
+// Config inject config body of plugin,used instead of its config files
+func (a *App) Config(name string, body string) *App {
+	a.SetPluginConfig(name, []byte(body))
+	return a
+}
+
+// Start run app with args in test mode and wait until all plugins are ready,
+// and grpc health statuses reflect the running plugins,so they report SERVING once Start returns.
+// The app is stopped when the test ends
+func (a *App) Start(args ...string) {
+	a.t.Helper()
+	if a.started {
+		a.t.Fatal("apptest: app already started")
+	}
+	a.started = true
+	args = append(append([]string{"--mode", application.AppModeTest}, a.freeEndpoints(args)...), args...)
+	go func() {
+		defer close(a.exited)
+		a.err = a.Execute(args...)
+	}()
+	a.t.Cleanup(a.Stop)
+	select {
+	case <-a.Ready():
+	case <-a.exited:
+		a.t.Fatalf("apptest: app exited before ready: %v", a.err)
+	case <-time.After(StartTimeout):
+		a.t.Fatalf("apptest: app not ready in %s", StartTimeout)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), StartTimeout)
+	defer cancel()
+	for _, plg := range a.Plugins() {
+		if s, ok := plg.(healthSyncer); ok {
+			if err := s.SyncHealth(ctx); err != nil {
+				a.t.Fatalf("apptest: health of plugin [%s] not synced: %v", plg.Name(), err)
+			}
+		}
+	}
+}
+
+// Stop cancel the app and wait for plugins stopped,errors returned by the app fail the test
+func (a *App) Stop() {
+	a.t.Helper()
+	if !a.started {
+		return
+	}
+	a.stopOnce.Do(func() {
+		a.cancel()
+		select {
+		case <-a.exited:
+			if a.err != nil {
+				a.t.Errorf("apptest: app stopped with error: %v", a.err)
+			}
+		case <-time.After(a.ShutdownTimeout + time.Second):
+			a.t.Errorf("apptest: app not stopped in %s", a.ShutdownTimeout)
+		}
+	})
+}
+
+// Addr listening address of plugin
+func (a *App) Addr(name string) net.Addr {
+	a.t.Helper()
+	for _, plg := range a.Plugins() {
+		if plg.Name() != name {
+			continue
+		}
+		addresser, ok := plg.(application.Addresser)
+		if !ok || addresser.Addr() == nil {
+			a.t.Fatalf("apptest: plugin [%s] is not listening", name)
+		}
+		return addresser.Addr()
+	}
+	a.t.Fatalf("apptest: plugin [%s] not used", name)
+	return nil
+}
+
+// Endpoint host:port of plugin listening address
+func (a *App) Endpoint(name string) string {
+	a.t.Helper()
+	return a.Addr(name).String()
+}
+
+// freeEndpoints args binding EndpointFlags of used plugins to free local ports
+func (a *App) freeEndpoints(args []string) []string {
+	a.t.Helper()
+	var endpoints []string
+	for _, plg := range a.Plugins() {
+		plg.Flags().VisitAll(func(f *pflag.Flag) {
+			if !contains(EndpointFlags, f.Name) || hasFlag(args, f.Name) {
+				return
+			}
+			port, err := utils.GetAvailablePort()
+			if err != nil {
+				a.t.Fatalf("apptest: get available port: %v", err)
+			}
+			endpoints = append(endpoints, fmt.Sprintf("--%s=127.0.0.1:%d", f.Name, port))
+		})
+	}
+	return endpoints
+}
+
+func hasFlag(args []string, name string) bool {
+	for _, arg := range args {
+		if arg == "--"+name || strings.HasPrefix(arg, "--"+name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
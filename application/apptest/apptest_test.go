@@ -0,0 +1,72 @@
+package apptest_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/no-mole/neptune/application/apptest"
+	"github.com/no-mole/neptune/health"
+	"github.com/no-mole/neptune/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestApp(t *testing.T) {
+	app := apptest.New(t)
+	app.Use(
+		server.NewGrpcServerPlugin(func(ctx context.Context) *grpc.Server {
+			return grpc.NewServer()
+		}),
+		server.NewHttpServerPlugin(func(ctx context.Context) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("pong"))
+			})
+		}),
+	)
+	app.Config("http-server", "http-health: false")
+	app.Start()
+
+	resp, err := http.Get("http://" + app.Endpoint("http-server") + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if string(body) != "pong" {
+		t.Fatalf("health handler should be disabled by injected config,got %q", body)
+	}
+
+	conn, err := grpc.NewClient(app.Endpoint("grpc-server"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	//no wait,Start waits for health statuses refreshed
+	for _, service := range []string{"", "grpc-server", "http-server"} {
+		check, err := grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if check.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+			t.Fatalf("grpc health of [%s] should be serving,got %s", service, check.Status)
+		}
+	}
+}
+
+func TestAppHealthIsolation(t *testing.T) {
+	t.Run("first", func(t *testing.T) {
+		app := apptest.New(t)
+		app.Use(server.NewHttpServerPlugin(func(ctx context.Context) http.Handler { return http.NotFoundHandler() }))
+		health.Register("http-server", "ping", health.CheckerFunc(func(ctx context.Context) error { return nil }))
+		app.Start()
+		if len(health.Components()) == 0 {
+			t.Fatal("running app should report its plugins")
+		}
+	})
+	if components := health.Components(); len(components) != 0 {
+		t.Fatalf("states and checkers of finished test app should be reset,got %v", components)
+	}
+}
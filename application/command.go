@@ -0,0 +1,99 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// Command named subcommand of app,such as migrate,consume or backfill.
+// It shares config loading,env prefix binding and logger with the app,but inits and runs only its own plugins
+type Command struct {
+	Use   string
+	Short string
+	Long  string
+
+	// Plugins names of plugins passed to App.Use to init and run,their hard dependencies are included automatically
+	Plugins []string
+	// With plugins used only by this command
+	With []Plugin
+
+	Hooks []HookFunc
+
+	// Run one-off job after all plugins are ready,the app is stopped when it returns.
+	// plugins run until the app is canceled when Run is nil
+	Run func(ctx context.Context, args []string) error
+}
+
+// AddCommand add named subcommands to app
+func (app *App) AddCommand(commands ...*Command) {
+	for _, c := range commands {
+		c := c
+		cmd := &cobra.Command{
+			Use:   c.Use,
+			Short: c.Short,
+			Long:  c.Long,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if c.Run == nil {
+					return app.run(c.Hooks, nil)
+				}
+				return app.run(c.Hooks, func(ctx context.Context) error {
+					return c.Run(ctx, args)
+				})
+			},
+		}
+		for _, plg := range c.With {
+			cmd.Flags().AddFlagSet(plg.Flags())
+		}
+		app.commands[cmd] = c
+		app.command.AddCommand(cmd)
+	}
+}
+
+// commandPlugins plugins used by the executing command
+func (app *App) commandPlugins(cmd *cobra.Command) ([]Plugin, error) {
+	c, ok := app.commands[cmd]
+	if !ok {
+		return app.plugins, nil
+	}
+	pool := append(append([]Plugin{}, app.plugins...), c.With...)
+	byName := make(map[string]Plugin, len(pool))
+	for _, plg := range pool {
+		byName[plg.Name()] = plg
+	}
+	selected := map[string]bool{}
+	queue := make([]string, 0, len(c.Plugins)+len(c.With))
+	for _, name := range c.Plugins {
+		if _, ok := byName[name]; !ok {
+			return nil, fmt.Errorf("command [%s] plugin [%s] not used", cmd.Name(), name)
+		}
+		queue = append(queue, name)
+	}
+	for _, plg := range c.With {
+		queue = append(queue, plg.Name())
+	}
+	//include hard dependencies,missing ones are reported by sortPlugins
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if selected[name] {
+			continue
+		}
+		selected[name] = true
+		if d, ok := byName[name].(Depender); ok {
+			for _, dep := range d.Dependencies() {
+				if _, ok := byName[dep]; ok {
+					queue = append(queue, dep)
+				}
+			}
+		}
+	}
+	plugins := make([]Plugin, 0, len(selected))
+	for _, plg := range pool {
+		if selected[plg.Name()] {
+			plugins = append(plugins, plg)
+		}
+	}
+	return plugins, nil
+}
@@ -0,0 +1,44 @@
+package application
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAddCommand(t *testing.T) {
+	app := New(context.Background())
+	app.Use(
+		newDependPlugin("grpc-server", nil),
+		newDependPlugin("database", []string{"config-center"}),
+		newDependPlugin("config-center", nil),
+	)
+	var used string
+	app.AddCommand(&Command{
+		Use:     "migrate",
+		Plugins: []string{"database"},
+		With:    []Plugin{newDependPlugin("migrator", []string{"database"})},
+		Run: func(ctx context.Context, args []string) error {
+			used = pluginNames(app.Plugins())
+			if len(args) != 1 || args[0] != "up" {
+				t.Errorf("unexpected args %v", args)
+			}
+			return nil
+		},
+	})
+	err := app.Execute("migrate", "up")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used != "config-center,database,migrator" {
+		t.Errorf("unexpected plugins %s", used)
+	}
+}
+
+func TestAddCommandUnknownPlugin(t *testing.T) {
+	app := New(context.Background())
+	app.AddCommand(&Command{Use: "consume", Plugins: []string{"consumer"}})
+	err := app.Execute("consume")
+	if err == nil {
+		t.Fatal("expect unknown plugin error")
+	}
+}
@@ -0,0 +1,165 @@
+package application
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// envPattern matches ${ENV_VAR} and ${ENV_VAR:-default}
+var envPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// interpolateEnv replace ${ENV_VAR} with the value of environment variable,
+// ${ENV_VAR:-default} uses default when the variable is unset or empty
+func interpolateEnv(body []byte) []byte {
+	return envPattern.ReplaceAllFunc(body, func(match []byte) []byte {
+		groups := envPattern.FindSubmatch(match)
+		if val, ok := os.LookupEnv(string(groups[1])); ok && val != "" {
+			return []byte(val)
+		}
+		return groups[3]
+	})
+}
+
+// configFileLayers find config files of plugin in merge order,
+// the base file in [. ./config] or ConfigFile,then the overlay in ./config/{mode}
+func configFileLayers(opts *PluginConfigOptions, mode string) []string {
+	var layers []string
+	name := opts.ConfigName
+	if opts.ConfigFile != "" {
+		if fileExist(opts.ConfigFile) {
+			layers = append(layers, opts.ConfigFile)
+		}
+		name = filepath.Base(opts.ConfigFile)
+	} else if base := findConfigFile([]string{".", "./config"}, name, opts.ConfigType); base != "" {
+		layers = append(layers, base)
+	}
+	overlay := findConfigFile([]string{filepath.Join("./config", mode)}, name, opts.ConfigType)
+	if overlay != "" && (len(layers) == 0 || !sameFile(layers[0], overlay)) {
+		layers = append(layers, overlay)
+	}
+	return layers
+}
+
+func findConfigFile(dirs []string, name, configType string) string {
+	for _, dir := range dirs {
+		candidates := []string{filepath.Join(dir, name)}
+		if configType != "" {
+			candidates = append(candidates, filepath.Join(dir, name+"."+configType))
+		}
+		for _, file := range candidates {
+			if fileExist(file) {
+				return file
+			}
+		}
+	}
+	return ""
+}
+
+func fileExist(file string) bool {
+	info, err := os.Stat(file)
+	return err == nil && !info.IsDir()
+}
+
+func sameFile(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
+// configTypeOf config type by opts or file extension
+func configTypeOf(configType, file string) string {
+	if configType == "" {
+		configType = strings.TrimPrefix(filepath.Ext(file), ".")
+	}
+	configType = strings.ToLower(configType)
+	if configType == "yml" {
+		return "yaml"
+	}
+	return configType
+}
+
+// mergeConfigFiles read files with env interpolated and deep merge them in order,
+// later files override earlier ones,the merged document is encoded in configType
+func mergeConfigFiles(files []string, configType string) ([]byte, error) {
+	var merged map[string]any
+	for i, file := range files {
+		body, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		body = interpolateEnv(body)
+		if len(files) == 1 {
+			//nothing to merge,keep the original document
+			return body, nil
+		}
+		doc, err := decodeConfig(body, configTypeOf(configType, file))
+		if err != nil {
+			return nil, fmt.Errorf("decode config file %s: %w", file, err)
+		}
+		if i == 0 {
+			merged = doc
+			continue
+		}
+		merged = deepMerge(merged, doc)
+	}
+	return encodeConfig(merged, configTypeOf(configType, files[0]))
+}
+
+func decodeConfig(body []byte, configType string) (map[string]any, error) {
+	doc := map[string]any{}
+	var err error
+	switch configType {
+	case "yaml":
+		err = yaml.Unmarshal(body, &doc)
+	case "json":
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		err = decoder.Decode(&doc)
+	case "toml":
+		err = toml.Unmarshal(body, &doc)
+	default:
+		return nil, fmt.Errorf("unsupported config type [%s] to merge", configType)
+	}
+	if doc == nil {
+		doc = map[string]any{}
+	}
+	return doc, err
+}
+
+func encodeConfig(doc map[string]any, configType string) ([]byte, error) {
+	switch configType {
+	case "yaml":
+		return yaml.Marshal(doc)
+	case "json":
+		return json.Marshal(doc)
+	case "toml":
+		return toml.Marshal(doc)
+	default:
+		return nil, fmt.Errorf("unsupported config type [%s] to merge", configType)
+	}
+}
+
+// deepMerge merge src into dst,nested maps are merged recursively,other values in src replace dst
+func deepMerge(dst, src map[string]any) map[string]any {
+	if dst == nil {
+		dst = map[string]any{}
+	}
+	for key, srcVal := range src {
+		srcMap, srcIsMap := srcVal.(map[string]any)
+		dstMap, dstIsMap := dst[key].(map[string]any)
+		if srcIsMap && dstIsMap {
+			dst[key] = deepMerge(dstMap, srcMap)
+			continue
+		}
+		dst[key] = srcVal
+	}
+	return dst
+}
@@ -0,0 +1,151 @@
+package application
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// newConfigCommand `config dump` print the effective config of plugins,
+// plugins are configured by config files and env but not initialized
+func (app *App) newConfigCommand() *cobra.Command {
+	var keys bool
+	dump := &cobra.Command{
+		Use:   "dump",
+		Short: "print the effective merged config of all plugins,or all known config keys with --keys",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			plugins, _, err := sortPlugins(app.plugins)
+			if err != nil {
+				return err
+			}
+			if keys {
+				return app.dumpConfigKeys(cmd.OutOrStdout(), plugins)
+			}
+			for _, plg := range plugins {
+				err = app.configurePlugin(plg)
+				if err != nil {
+					return err
+				}
+			}
+			return app.dumpConfig(cmd.OutOrStdout(), plugins)
+		},
+	}
+	dump.Flags().BoolVar(&keys, "keys", false, "list all known config keys with their flags,env and defaults")
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "inspect application config",
+	}
+	cmd.AddCommand(dump)
+	return cmd
+}
+
+// ConfigDocument effective config of plugin,plugins not implementing ConfigValuer are described by their flags
+func ConfigDocument(plg Plugin) (any, error) {
+	if v, ok := plg.(ConfigValuer); ok {
+		//round trip to apply yaml tags
+		body, err := yaml.Marshal(v.ConfigValue())
+		if err != nil {
+			return nil, err
+		}
+		doc := map[string]any{}
+		err = yaml.Unmarshal(body, &doc)
+		return doc, err
+	}
+	doc := map[string]any{}
+	plg.Flags().VisitAll(func(f *pflag.Flag) {
+		doc[f.Name] = f.Value.String()
+	})
+	return doc, nil
+}
+
+func (app *App) dumpConfig(w io.Writer, plugins []Plugin) error {
+	docs := map[string]any{}
+	for _, plg := range plugins {
+		doc, err := ConfigDocument(plg)
+		if err != nil {
+			return fmt.Errorf("plugin [%s] config: %w", plg.Name(), err)
+		}
+		docs[plg.Name()] = RedactConfig(doc)
+	}
+	body, err := yaml.Marshal(docs)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+func (app *App) dumpConfigKeys(w io.Writer, plugins []Plugin) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "PLUGIN\tKEY\tFLAG\tENV\tDEFAULT\tUSAGE")
+	for _, plg := range plugins {
+		keys := map[string]string{}
+		if v, ok := plg.(ConfigValuer); ok {
+			for _, field := range v.ConfigFields() {
+				keys[field.Flag] = field.Key
+			}
+		}
+		var flags []*pflag.Flag
+		plg.Flags().VisitAll(func(f *pflag.Flag) {
+			flags = append(flags, f)
+		})
+		sort.Slice(flags, func(i, j int) bool {
+			return flags[i].Name < flags[j].Name
+		})
+		envPrefix := app.pluginEnvPrefix(plg)
+		for _, f := range flags {
+			key, ok := keys[f.Name]
+			if !ok {
+				key = f.Name
+			}
+			env := strings.ToUpper(envPrefix + "_" + strings.ReplaceAll(f.Name, "-", "_"))
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t--%s\t%s\t%s\t%s\n", plg.Name(), key, f.Name, env, f.DefValue, f.Usage)
+		}
+	}
+	return tw.Flush()
+}
+
+var secretKeywords = []string{"password", "secret", "token", "credential", "private"}
+
+// RedactConfig replace values of keys looking like secrets in config document with ******
+func RedactConfig(doc any) any {
+	switch v := doc.(type) {
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for key, val := range v {
+			if isSecretKey(key) && val != nil && val != "" {
+				redacted[key] = "******"
+				continue
+			}
+			redacted[key] = RedactConfig(val)
+		}
+		return redacted
+	case []any:
+		redacted := make([]any, 0, len(v))
+		for _, val := range v {
+			redacted = append(redacted, RedactConfig(val))
+		}
+		return redacted
+	default:
+		return doc
+	}
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, keyword := range secretKeywords {
+		if strings.Contains(key, keyword) {
+			return true
+		}
+	}
+	return false
+}
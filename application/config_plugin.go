@@ -0,0 +1,265 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	validate "github.com/go-playground/validator/v10"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+var validator = validate.New()
+
+// ConfigField a config key derived from a tagged config struct field
+type ConfigField struct {
+	Flag    string //flag name,also the env key
+	Key     string //yaml key
+	Default string
+	Usage   string
+
+	index []int
+}
+
+// NewConfigPlugin create a plugin whose config is described by the tagged struct conf.
+// Fields tagged with `flag` become flags (and env bindings) named FlagPrefix+flag,
+// with defaults from `default` and usages from `usage` tags;
+// config files are decoded by `yaml`/`json`/`toml` tags and the result is validated by `validate` tags before Init.
+//
+//	type Conf struct {
+//		Endpoint string `yaml:"endpoint" flag:"endpoint" default:"0.0.0.0:80" usage:"server endpoint" validate:"required"`
+//	}
+//
+// Invalid tags such as a default not parsable as the field type are returned by Config and ValidateConfig,
+// so the app fails to start with the error
+func NewConfigPlugin[T any](name string, opts *PluginConfigOptions, conf *T) *ConfigPlugin[T] {
+	plg := &ConfigPlugin[T]{
+		Plugin: NewPluginConfig(name, opts),
+		conf:   conf,
+	}
+	fields, err := BindConfigFlags(plg.Flags(), opts.FlagPrefix, conf)
+	if err != nil {
+		plg.err = fmt.Errorf("plugin [%s] config: %w", name, err)
+	}
+	plg.fields = fields
+	return plg
+}
+
+type ConfigPlugin[T any] struct {
+	Plugin
+	conf   *T
+	fields []*ConfigField
+	err    error //error of binding config flags
+}
+
+// Config decode config file body by ConfigType,flags set in command line keep their values
+func (p *ConfigPlugin[T]) Config(_ context.Context, body []byte) error {
+	if p.err != nil {
+		return p.err
+	}
+	decoded, err := p.decode(body)
+	if err != nil {
+		return err
+	}
+	*p.conf = *decoded
+	return nil
+}
+
+// Reload decode the re-read config file body like Config,the config is replaced only when the new one is valid.
+// Plugins holding resources built from the config should override it to rebuild them
+func (p *ConfigPlugin[T]) Reload(_ context.Context, body []byte) error {
+	if p.err != nil {
+		return p.err
+	}
+	decoded, err := p.decode(body)
+	if err != nil {
+		return err
+	}
+	err = validator.Struct(decoded)
+	if err != nil {
+		return err
+	}
+	*p.conf = *decoded
+	return nil
+}
+
+// decode body into a copy of conf,values of flags set in command line or env are kept
+func (p *ConfigPlugin[T]) decode(body []byte) (*T, error) {
+	decoded := *p.conf
+	err := DecodeConfig(body, p.ConfigOptions().ConfigType, &decoded)
+	if err != nil {
+		return nil, err
+	}
+	cur, dst := reflect.ValueOf(p.conf).Elem(), reflect.ValueOf(&decoded).Elem()
+	for _, field := range p.fields {
+		//flags are parsed by the app command,so check Changed instead of Visit
+		if f := p.Flags().Lookup(field.Flag); f != nil && f.Changed {
+			dst.FieldByIndex(field.index).Set(cur.FieldByIndex(field.index))
+		}
+	}
+	return &decoded, nil
+}
+
+// Conf the bound config struct
+func (p *ConfigPlugin[T]) Conf() *T {
+	return p.conf
+}
+
+func (p *ConfigPlugin[T]) ValidateConfig() error {
+	if p.err != nil {
+		return p.err
+	}
+	return validator.Struct(p.conf)
+}
+
+func (p *ConfigPlugin[T]) ConfigValue() any {
+	return p.conf
+}
+
+func (p *ConfigPlugin[T]) ConfigFields() []*ConfigField {
+	return p.fields
+}
+
+// DecodeConfig decode body into conf by configType [yaml|json|toml],default is yaml
+func DecodeConfig(body []byte, configType string, conf any) error {
+	switch configTypeOf(configType, "") {
+	case "json":
+		return json.Unmarshal(body, conf)
+	case "toml":
+		return toml.Unmarshal(body, conf)
+	default:
+		return yaml.Unmarshal(body, conf)
+	}
+}
+
+// BindConfigFlags define a flag in set for every field of the struct pointer conf tagged with `flag`,
+// embedded structs are walked recursively
+func BindConfigFlags(set *pflag.FlagSet, prefix string, conf any) ([]*ConfigField, error) {
+	val := reflect.ValueOf(conf)
+	if val.Kind() != reflect.Pointer || val.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("config must be a pointer to struct,got %T", conf)
+	}
+	return bindStructFlags(set, prefix, val.Elem(), nil)
+}
+
+func bindStructFlags(set *pflag.FlagSet, prefix string, val reflect.Value, index []int) ([]*ConfigField, error) {
+	var fields []*ConfigField
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			embedded, err := bindStructFlags(set, prefix, val.Field(i), append(append([]int{}, index...), i))
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, embedded...)
+			continue
+		}
+		name, ok := sf.Tag.Lookup("flag")
+		if !ok || name == "" || name == "-" || !sf.IsExported() {
+			continue
+		}
+		field := &ConfigField{
+			Flag:    prefix + name,
+			Key:     tagName(sf, "yaml"),
+			Default: sf.Tag.Get("default"),
+			Usage:   sf.Tag.Get("usage"),
+			index:   append(append([]int{}, index...), i),
+		}
+		err := defineFlag(set, field, val.Field(i).Addr().Interface())
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", sf.Name, err)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func defineFlag(set *pflag.FlagSet, field *ConfigField, ptr any) (err error) {
+	def := field.Default
+	switch p := ptr.(type) {
+	case *string:
+		set.StringVar(p, field.Flag, def, field.Usage)
+	case *bool:
+		var v bool
+		if def != "" {
+			v, err = strconv.ParseBool(def)
+		}
+		set.BoolVar(p, field.Flag, v, field.Usage)
+	case *int:
+		var v int
+		if def != "" {
+			v, err = strconv.Atoi(def)
+		}
+		set.IntVar(p, field.Flag, v, field.Usage)
+	case *int64:
+		var v int64
+		if def != "" {
+			v, err = strconv.ParseInt(def, 10, 64)
+		}
+		set.Int64Var(p, field.Flag, v, field.Usage)
+	case *uint:
+		var v uint64
+		if def != "" {
+			v, err = strconv.ParseUint(def, 10, 0)
+		}
+		set.UintVar(p, field.Flag, uint(v), field.Usage)
+	case *uint64:
+		var v uint64
+		if def != "" {
+			v, err = strconv.ParseUint(def, 10, 64)
+		}
+		set.Uint64Var(p, field.Flag, v, field.Usage)
+	case *float64:
+		var v float64
+		if def != "" {
+			v, err = strconv.ParseFloat(def, 64)
+		}
+		set.Float64Var(p, field.Flag, v, field.Usage)
+	case *time.Duration:
+		var v time.Duration
+		if def != "" {
+			v, err = time.ParseDuration(def)
+		}
+		set.DurationVar(p, field.Flag, v, field.Usage)
+	case *[]string:
+		var v []string
+		if def != "" {
+			v = strings.Split(def, ",")
+		}
+		set.StringSliceVar(p, field.Flag, v, field.Usage)
+	case *map[string]string:
+		var v map[string]string
+		if def != "" {
+			v = map[string]string{}
+			for _, pair := range strings.Split(def, ",") {
+				kv := strings.SplitN(pair, "=", 2)
+				if len(kv) != 2 {
+					return fmt.Errorf("invalid default %q: %s must be formatted as key=value", def, pair)
+				}
+				v[kv[0]] = kv[1]
+			}
+		}
+		set.StringToStringVar(p, field.Flag, v, field.Usage)
+	default:
+		return fmt.Errorf("unsupported config flag type %T", ptr)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid default %q: %w", def, err)
+	}
+	return nil
+}
+
+func tagName(sf reflect.StructField, tag string) string {
+	name := strings.Split(sf.Tag.Get(tag), ",")[0]
+	if name == "" {
+		return strings.ToLower(sf.Name)
+	}
+	return name
+}
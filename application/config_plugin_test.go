@@ -0,0 +1,200 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+type testConfig struct {
+	Endpoint string            `yaml:"endpoint" flag:"endpoint" default:"0.0.0.0:80" usage:"server endpoint" validate:"required"`
+	Timeout  time.Duration     `yaml:"timeout" flag:"timeout" default:"3s" usage:"request timeout"`
+	Level    string            `yaml:"level" flag:"level" default:"info"`
+	Retries  int               `yaml:"retries" flag:"retries" default:"1" validate:"min=0,max=5"`
+	Password string            `yaml:"password" flag:"password"`
+	Tags     map[string]string `yaml:"tags" flag:"tags" default:"zone=a"`
+	Ignored  string            `yaml:"ignored"`
+}
+
+func newTestConfigPlugin() *ConfigPlugin[testConfig] {
+	return NewConfigPlugin("test", &PluginConfigOptions{ConfigName: "test.yaml", EnvPrefix: "test", FlagPrefix: "test-"}, &testConfig{})
+}
+
+func TestBindConfigFlags(t *testing.T) {
+	set := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	conf := &testConfig{}
+	fields, err := BindConfigFlags(set, "test-", conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 6 {
+		t.Fatalf("fields without flag tag should be skipped,got %d fields", len(fields))
+	}
+	f := set.Lookup("test-endpoint")
+	if f == nil || f.DefValue != "0.0.0.0:80" || f.Usage != "server endpoint" {
+		t.Fatalf("unexpected flag %+v", f)
+	}
+	if conf.Endpoint != "0.0.0.0:80" || conf.Timeout != 3*time.Second || conf.Retries != 1 || conf.Tags["zone"] != "a" {
+		t.Fatalf("defaults should be applied to conf,got %+v", conf)
+	}
+	err = set.Parse([]string{"--test-timeout", "5s", "--test-tags", "zone=b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Timeout != 5*time.Second || conf.Tags["zone"] != "b" {
+		t.Fatalf("flags should be bound to conf,got %+v", conf)
+	}
+
+	_, err = BindConfigFlags(set, "", testConfig{})
+	if err == nil {
+		t.Fatal("expect error of non pointer config")
+	}
+}
+
+func TestConfigPluginInvalidDefault(t *testing.T) {
+	conf := &struct {
+		Port int `yaml:"port" flag:"port" default:"http"`
+	}{}
+	plg := NewConfigPlugin("invalid", &PluginConfigOptions{}, conf)
+	if err := plg.ValidateConfig(); err == nil || !strings.Contains(err.Error(), `invalid default "http"`) {
+		t.Fatalf("expect invalid default error,got %v", err)
+	}
+
+	app := New(context.Background())
+	app.Use(plg)
+	if err := app.Execute(); err == nil {
+		t.Fatal("app should fail to start with invalid default")
+	}
+}
+
+func TestConfigPluginPrecedence(t *testing.T) {
+	//endpoint from flag,timeout from env,level from file,retries default
+	t.Setenv("NEPTUNE_TEST_TEST_ENDPOINT", "env:80")
+	t.Setenv("NEPTUNE_TEST_TEST_TIMEOUT", "7s")
+	plg := newTestConfigPlugin()
+	app := New(context.Background())
+	app.Use(plg)
+	app.SetPluginConfig("test", []byte("endpoint: file:80\ntimeout: 6s\nlevel: debug\n"))
+	err := app.Execute("--test-endpoint", "flag:80")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := plg.Conf()
+	if conf.Endpoint != "flag:80" || conf.Timeout != 7*time.Second || conf.Level != "debug" || conf.Retries != 1 {
+		t.Fatalf("precedence should be flag > env > file > default,got %+v", conf)
+	}
+}
+
+func TestConfigPluginValidate(t *testing.T) {
+	for _, body := range []string{"retries: -1\n", "retries: 10\n"} {
+		plg := newTestConfigPlugin()
+		app := New(context.Background())
+		app.Use(plg)
+		app.SetPluginConfig("test", []byte(body))
+		err := app.Execute()
+		if err == nil || !strings.Contains(err.Error(), "plugin [test] config invalid") {
+			t.Fatalf("config %q should be rejected by validate tags,got %v", body, err)
+		}
+	}
+}
+
+func TestConfigDump(t *testing.T) {
+	dump := func(args ...string) string {
+		app := New(context.Background())
+		app.Use(newTestConfigPlugin())
+		app.SetPluginConfig("test", []byte("password: s3cr3t\nlevel: debug\n"))
+		out := &bytes.Buffer{}
+		app.command.SetOut(out)
+		err := app.Execute(append([]string{"config", "dump"}, args...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out.String()
+	}
+
+	docs := map[string]map[string]any{}
+	err := yaml.Unmarshal([]byte(dump()), &docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docs["test"]["password"] != "******" {
+		t.Fatalf("password should be redacted,got %v", docs["test"]["password"])
+	}
+	if docs["test"]["level"] != "debug" || docs["test"]["endpoint"] != "0.0.0.0:80" {
+		t.Fatalf("unexpected effective config %v", docs["test"])
+	}
+
+	keys := dump("--keys")
+	if !strings.HasPrefix(keys, "PLUGIN") {
+		t.Fatalf("keys should be printed as table,got %s", keys)
+	}
+	var line string
+	for _, l := range strings.Split(keys, "\n") {
+		if strings.Contains(l, "--test-endpoint") {
+			line = l
+		}
+	}
+	for _, want := range []string{"test", "endpoint", "NEPTUNE_TEST_TEST_ENDPOINT", "0.0.0.0:80", "server endpoint"} {
+		if !strings.Contains(line, want) {
+			t.Fatalf("key line should contain %q,got %q", want, line)
+		}
+	}
+	if strings.Contains(keys, "s3cr3t") {
+		t.Fatal("keys should not print config values")
+	}
+}
+
+type blockingConfigPlugin struct {
+	*ConfigPlugin[testConfig]
+}
+
+func (b *blockingConfigPlugin) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func TestConfigPluginReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "test.yaml")
+	_ = os.WriteFile(file, []byte("level: debug\nretries: 2\n"), 0644)
+	plg := NewConfigPlugin("test", &PluginConfigOptions{ConfigFile: file, FlagPrefix: "test-"}, &testConfig{})
+	app := New(context.Background())
+	app.Use(&blockingConfigPlugin{ConfigPlugin: plg})
+	exited := make(chan error, 1)
+	go func() {
+		exited <- app.Execute("--test-endpoint", "flag:80")
+	}()
+	select {
+	case <-app.Ready():
+	case err := <-exited:
+		t.Fatal(err)
+	}
+	defer func() {
+		app.Cancel("test finished")
+		if err := <-exited; err != nil {
+			t.Error(err)
+		}
+	}()
+
+	_ = os.WriteFile(file, []byte("level: warn\nretries: 3\nendpoint: file:80\n"), 0644)
+	app.reload()
+	conf := plg.Conf()
+	if conf.Level != "warn" || conf.Retries != 3 {
+		t.Fatalf("config should be reloaded from file,got %+v", conf)
+	}
+	if conf.Endpoint != "flag:80" {
+		t.Fatalf("flag should keep its value after reload,got %s", conf.Endpoint)
+	}
+
+	_ = os.WriteFile(file, []byte("level: error\nretries: 10\n"), 0644)
+	app.reload()
+	if conf = plg.Conf(); conf.Level != "warn" || conf.Retries != 3 {
+		t.Fatalf("invalid config should not be applied,got %+v", conf)
+	}
+}
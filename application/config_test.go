@@ -0,0 +1,41 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMergeConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "app.yaml")
+	overlay := filepath.Join(dir, "prod.yaml")
+	_ = os.WriteFile(base, []byte("grpc-endpoint: 0.0.0.0:8080\ndatabase:\n  host: localhost\n  port: 3306\n"), 0644)
+	_ = os.WriteFile(overlay, []byte("database:\n  host: ${NEPTUNE_TEST_DB_HOST}\n  password: ${NEPTUNE_TEST_DB_PASSWORD:-secret}\n"), 0644)
+	t.Setenv("NEPTUNE_TEST_DB_HOST", "db.prod")
+
+	body, err := mergeConfigFiles([]string{base, overlay}, "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := struct {
+		GrpcEndpoint string `yaml:"grpc-endpoint"`
+		Database     struct {
+			Host     string `yaml:"host"`
+			Port     int    `yaml:"port"`
+			Password string `yaml:"password"`
+		} `yaml:"database"`
+	}{}
+	err = yaml.Unmarshal(body, &conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.GrpcEndpoint != "0.0.0.0:8080" || conf.Database.Port != 3306 {
+		t.Errorf("base values lost: %s", body)
+	}
+	if conf.Database.Host != "db.prod" || conf.Database.Password != "secret" {
+		t.Errorf("overlay values not applied: %s", body)
+	}
+}
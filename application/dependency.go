@@ -0,0 +1,139 @@
+package application
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Depender is implemented by plugins requiring other plugins,
+// required plugins must be used by the app,they are initialized before and ready before this plugin runs
+type Depender interface {
+	Dependencies() []string
+}
+
+// OptionalDepender is implemented by plugins which should be initialized after other plugins when they are used,
+// unused optional dependencies are ignored
+type OptionalDepender interface {
+	OptionalDependencies() []string
+}
+
+// Readier is implemented by plugins which need time to be ready after Run was called,
+// plugins depending on it run after the returned channel closed.
+// Plugins not implementing Readier are ready once Run was called.
+type Readier interface {
+	Ready() <-chan struct{}
+}
+
+// pluginDependencies return names of used plugins that plg depends on
+func pluginDependencies(plg Plugin, used map[string]Plugin) ([]string, error) {
+	var deps []string
+	if d, ok := plg.(Depender); ok {
+		for _, name := range d.Dependencies() {
+			if _, exist := used[name]; !exist {
+				return nil, fmt.Errorf("plugin [%s] depends on plugin [%s] which is not used", plg.Name(), name)
+			}
+			deps = append(deps, name)
+		}
+	}
+	if d, ok := plg.(OptionalDepender); ok {
+		for _, name := range d.OptionalDependencies() {
+			if _, exist := used[name]; exist {
+				deps = append(deps, name)
+			}
+		}
+	}
+	return deps, nil
+}
+
+// sortPlugins sort plugins topologically by dependencies,
+// plugins without dependency relationship keep their registration order
+func sortPlugins(plugins []Plugin) ([]Plugin, map[string][]string, error) {
+	used := make(map[string]Plugin, len(plugins))
+	for _, plg := range plugins {
+		if _, exist := used[plg.Name()]; exist {
+			return nil, nil, fmt.Errorf("plugin [%s] used more than once", plg.Name())
+		}
+		used[plg.Name()] = plg
+	}
+	graph := make(map[string][]string, len(plugins))
+	for _, plg := range plugins {
+		deps, err := pluginDependencies(plg, used)
+		if err != nil {
+			return nil, nil, err
+		}
+		graph[plg.Name()] = deps
+	}
+
+	sorted := make([]Plugin, 0, len(plugins))
+	placed := make(map[string]bool, len(plugins))
+	for len(sorted) < len(plugins) {
+		progress := false
+		for _, plg := range plugins {
+			if placed[plg.Name()] || !allPlaced(graph[plg.Name()], placed) {
+				continue
+			}
+			sorted = append(sorted, plg)
+			placed[plg.Name()] = true
+			progress = true
+			//restart from the head to keep registration order stable
+			break
+		}
+		if !progress {
+			return nil, nil, fmt.Errorf("plugin dependency cycle: %s", findCycle(plugins, graph, placed))
+		}
+	}
+	return sorted, graph, nil
+}
+
+func allPlaced(names []string, placed map[string]bool) bool {
+	for _, name := range names {
+		if !placed[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// findCycle find a dependency cycle in plugins not placed yet,like a -> b -> a
+func findCycle(plugins []Plugin, graph map[string][]string, placed map[string]bool) string {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	var path []string
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range graph[name] {
+			if placed[dep] {
+				continue
+			}
+			switch state[dep] {
+			case visiting:
+				for i, n := range path {
+					if n == dep {
+						return append(append([]string{}, path[i:]...), dep)
+					}
+				}
+			case 0:
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+	for _, plg := range plugins {
+		if placed[plg.Name()] || state[plg.Name()] != 0 {
+			continue
+		}
+		if cycle := visit(plg.Name()); cycle != nil {
+			return strings.Join(cycle, " -> ")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package application
+
+import (
+	"strings"
+	"testing"
+)
+
+type dependPlugin struct {
+	Plugin
+	deps     []string
+	optional []string
+}
+
+func (d *dependPlugin) Dependencies() []string {
+	return d.deps
+}
+
+func (d *dependPlugin) OptionalDependencies() []string {
+	return d.optional
+}
+
+func newDependPlugin(name string, deps []string, optional ...string) Plugin {
+	return &dependPlugin{
+		Plugin:   NewPluginConfig(name, &PluginConfigOptions{}),
+		deps:     deps,
+		optional: optional,
+	}
+}
+
+func pluginNames(plugins []Plugin) string {
+	names := make([]string, 0, len(plugins))
+	for _, plg := range plugins {
+		names = append(names, plg.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+func TestSortPlugins(t *testing.T) {
+	plugins := []Plugin{
+		newDependPlugin("grpc-server", nil, "config-center", "grpc-register", "not-used"),
+		newDependPlugin("http-server", nil),
+		newDependPlugin("grpc-register", []string{"config-center"}),
+		newDependPlugin("config-center", nil),
+	}
+	sorted, graph, err := sortPlugins(plugins)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := pluginNames(sorted); got != "http-server,config-center,grpc-register,grpc-server" {
+		t.Errorf("unexpected order %s", got)
+	}
+	if len(graph["grpc-server"]) != 2 {
+		t.Errorf("unused optional dependency should be ignored,got %v", graph["grpc-server"])
+	}
+}
+
+func TestSortPluginsError(t *testing.T) {
+	_, _, err := sortPlugins([]Plugin{newDependPlugin("a", []string{"b"})})
+	if err == nil || !strings.Contains(err.Error(), "not used") {
+		t.Errorf("expect missing dependency error,got %v", err)
+	}
+
+	_, _, err = sortPlugins([]Plugin{
+		newDependPlugin("a", []string{"b"}),
+		newDependPlugin("b", []string{"c"}),
+		newDependPlugin("c", []string{"a"}),
+	})
+	if err == nil || !strings.Contains(err.Error(), "a -> b -> c -> a") {
+		t.Errorf("expect cycle error,got %v", err)
+	}
+
+	_, _, err = sortPlugins([]Plugin{newDependPlugin("a", nil), newDependPlugin("a", nil)})
+	if err == nil {
+		t.Error("expect duplicated plugin error")
+	}
+}
@@ -2,6 +2,8 @@ package application
 
 import (
 	"context"
+	"net"
+
 	"github.com/spf13/pflag"
 )
 
@@ -14,11 +16,46 @@ type Plugin interface {
 	Run(ctx context.Context) error
 }
 
+// Stopper is implemented by plugins that need to release resources when the app shuts down.
+// Stop is called in reverse initialization order, ctx is canceled when the shutdown timeout is exceeded.
+type Stopper interface {
+	Stop(ctx context.Context) error
+}
+
+// Reloader is implemented by plugins able to apply config changes without restart,
+// Reload is called with the re-read config file body when the app receives SIGHUP
+type Reloader interface {
+	Reload(ctx context.Context, config []byte) error
+}
+
+// Addresser is implemented by plugins listening on a network address
+type Addresser interface {
+	Addr() net.Addr
+}
+
+// Restarter is implemented by plugins telling whether Run can be called again after it returned,
+// plugins not implementing it are restartable. PolicyRestart is rejected for plugins not restartable
+type Restarter interface {
+	Restartable() bool
+}
+
+// ConfigValidator is implemented by plugins validating config after config files and env applied,before Init
+type ConfigValidator interface {
+	ValidateConfig() error
+}
+
+// ConfigValuer is implemented by plugins exposing the effective config and the keys it accepts
+type ConfigValuer interface {
+	ConfigValue() any
+	ConfigFields() []*ConfigField
+}
+
 type PluginConfigOptions struct {
 	ConfigFile string
 	ConfigName string
 	ConfigType string
 	EnvPrefix  string
+	FlagPrefix string //prefix of flags derived from config struct,see NewConfigPlugin
 }
 
 func NewPluginConfig(name string, opts *PluginConfigOptions) Plugin {
@@ -0,0 +1,129 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/no-mole/neptune/health"
+	"github.com/no-mole/neptune/logger"
+)
+
+type SupervisePolicy string
+
+const (
+	// PolicyFailFast an error returned by Run stops the app,the default policy
+	PolicyFailFast SupervisePolicy = "fail-fast"
+	// PolicyRestart Run is called again with exponential backoff when it returns an error,
+	// plugins must be restartable,see Restarter
+	PolicyRestart SupervisePolicy = "restart"
+	// PolicyIgnore an error returned by Run is logged and the plugin stays exited without failing readiness
+	PolicyIgnore SupervisePolicy = "ignore"
+)
+
+var ErrRestartUnsupported = errors.New("restart policy unsupported,Run can not be called again")
+
+var (
+	DefaultRestartBackoff    = time.Second
+	DefaultMaxRestartBackoff = time.Minute
+)
+
+// Supervision how the app handles errors and panics of plugin Run
+type Supervision struct {
+	Policy      SupervisePolicy
+	MaxRestarts int           //max restarts before the error stops the app,0 is unlimited
+	Backoff     time.Duration //first restart backoff,doubled on each restart,default is 1s
+	MaxBackoff  time.Duration //max restart backoff,backoff is reset after running longer than it,default is 1m
+}
+
+// UseWithSupervision use plugins with supervision policy,plugins used by Use are fail-fast
+func (app *App) UseWithSupervision(s Supervision, plugins ...Plugin) {
+	if s.Backoff <= 0 {
+		s.Backoff = DefaultRestartBackoff
+	}
+	if s.MaxBackoff < s.Backoff {
+		s.MaxBackoff = DefaultMaxRestartBackoff
+		if s.MaxBackoff < s.Backoff {
+			s.MaxBackoff = s.Backoff
+		}
+	}
+	for _, plg := range plugins {
+		app.supervisions[plg.Name()] = s
+	}
+	app.Use(plugins...)
+}
+
+// validateSupervision restart policy requires plugin restartable
+func (app *App) validateSupervision(plg Plugin) error {
+	if app.supervisions[plg.Name()].Policy != PolicyRestart {
+		return nil
+	}
+	if r, ok := plg.(Restarter); ok && !r.Restartable() {
+		return ErrRestartUnsupported
+	}
+	return nil
+}
+
+// supervise run plugin with its supervision policy,panics are recovered as errors
+func (app *App) supervise(ctx context.Context, plg Plugin) error {
+	s, ok := app.supervisions[plg.Name()]
+	if !ok {
+		s.Policy = PolicyFailFast
+	}
+	backoff := s.Backoff
+	restarts := 0
+	for {
+		start := time.Now()
+		err := runPlugin(ctx, plg)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+		switch s.Policy {
+		case PolicyIgnore:
+			logger.Error(app.ctx, "plugin run error ignored", err, logger.WithField("pluginName", plg.Name()))
+			app.setState(plg.Name(), health.StateExited)
+			health.SetRestarts(plg.Name(), restarts, err)
+			return nil
+		case PolicyRestart:
+			if s.MaxRestarts > 0 && restarts >= s.MaxRestarts {
+				return fmt.Errorf("plugin [%s] restarted %d times: %w", plg.Name(), restarts, err)
+			}
+		default:
+			return err
+		}
+		if time.Since(start) > s.MaxBackoff {
+			backoff = s.Backoff
+		}
+		restarts++
+		app.setState(plg.Name(), health.StateRestarting)
+		health.SetRestarts(plg.Name(), restarts, err)
+		logger.Warning(app.ctx, "plugin restarting", err,
+			logger.WithField("pluginName", plg.Name()),
+			logger.WithField("restarts", restarts),
+			logger.WithField("backoff", backoff.String()),
+		)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+		backoff *= 2
+		if backoff > s.MaxBackoff {
+			backoff = s.MaxBackoff
+		}
+		app.setState(plg.Name(), health.StateRunning)
+	}
+}
+
+func runPlugin(ctx context.Context, plg Plugin) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("plugin [%s] panic: %v\n%s", plg.Name(), r, debug.Stack())
+		}
+	}()
+	return plg.Run(ctx)
+}
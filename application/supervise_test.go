@@ -0,0 +1,120 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/no-mole/neptune/health"
+)
+
+type flakyPlugin struct {
+	Plugin
+	runs  atomic.Int32
+	fails int32
+	panic bool
+}
+
+func (f *flakyPlugin) Run(ctx context.Context) error {
+	if f.runs.Add(1) <= f.fails {
+		if f.panic {
+			panic("boom")
+		}
+		return errors.New("flaky")
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func newFlakyPlugin(name string, fails int32) *flakyPlugin {
+	return &flakyPlugin{Plugin: NewPluginConfig(name, &PluginConfigOptions{}), fails: fails}
+}
+
+func TestSuperviseRestart(t *testing.T) {
+	app := New(context.Background())
+	plg := newFlakyPlugin("consumer", 2)
+	app.UseWithSupervision(Supervision{Policy: PolicyRestart, Backoff: time.Millisecond}, plg)
+	app.AddCommand(&Command{
+		Use:     "consume",
+		Plugins: []string{"consumer"},
+		Run: func(ctx context.Context, args []string) error {
+			for plg.runs.Load() < 3 {
+				time.Sleep(time.Millisecond)
+			}
+			return nil
+		},
+	})
+	err := app.Execute("consume")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := health.Liveness(context.Background()).Components["consumer"]
+	if report.Restarts != 2 || report.LastError != "flaky" {
+		t.Errorf("unexpected restarts report %+v", report)
+	}
+}
+
+func TestSuperviseMaxRestarts(t *testing.T) {
+	app := New(context.Background())
+	app.UseWithSupervision(Supervision{Policy: PolicyRestart, MaxRestarts: 1, Backoff: time.Millisecond}, newFlakyPlugin("watcher", 5))
+	err := app.Execute()
+	if err == nil || !strings.Contains(err.Error(), "restarted 1 times") {
+		t.Errorf("expect max restarts error,got %v", err)
+	}
+}
+
+type oneShotPlugin struct {
+	*flakyPlugin
+}
+
+func (oneShotPlugin) Restartable() bool { return false }
+
+func TestSuperviseRestartUnsupported(t *testing.T) {
+	app := New(context.Background())
+	app.UseWithSupervision(Supervision{Policy: PolicyRestart}, oneShotPlugin{flakyPlugin: newFlakyPlugin("server", 1)})
+	err := app.Execute()
+	if !errors.Is(err, ErrRestartUnsupported) || !strings.Contains(err.Error(), "plugin [server]") {
+		t.Errorf("restart policy of plugin not restartable should be rejected,got %v", err)
+	}
+}
+
+func TestSupervisePanic(t *testing.T) {
+	app := New(context.Background())
+	plg := newFlakyPlugin("panicking", 1)
+	plg.panic = true
+	app.Use(plg)
+	err := app.Execute()
+	if err == nil || !strings.Contains(err.Error(), "panic: boom") {
+		t.Errorf("expect recovered panic error,got %v", err)
+	}
+}
+
+func TestSuperviseIgnore(t *testing.T) {
+	app := New(context.Background())
+	app.Use(newFlakyPlugin("server", 0))
+	app.UseWithSupervision(Supervision{Policy: PolicyIgnore}, newFlakyPlugin("optional", 1))
+	app.AddCommand(&Command{
+		Use:     "serve",
+		Plugins: []string{"server", "optional"},
+		Run: func(ctx context.Context, args []string) error {
+			for app.PluginState("optional") != health.StateExited {
+				time.Sleep(time.Millisecond)
+			}
+			report := health.Readiness(ctx).Components
+			if report["optional"].Status != health.StatusUp || report["optional"].LastError != "flaky" {
+				t.Errorf("ignored plugin should not fail readiness,got %+v", report["optional"])
+			}
+			if report["server"].Status != health.StatusUp {
+				t.Errorf("unexpected server readiness %+v", report["server"])
+			}
+			return nil
+		},
+	})
+	err := app.Execute("serve")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,22 @@
+package config
+
+import (
+	"github.com/no-mole/neptune/config"
+	"github.com/spf13/cobra"
+)
+
+var Command = &cobra.Command{
+	Use:   "config",
+	Short: "Manage values of the config center",
+}
+
+var (
+	keyEnv  string
+	keyFile string
+)
+
+func init() {
+	Command.PersistentFlags().StringVar(&keyEnv, "key-env", config.DefaultSecretKeyEnv, "env var holding the base64 encoded aes key")
+	Command.PersistentFlags().StringVar(&keyFile, "key-file", "", "file holding the base64 encoded aes key,preferred over --key-env")
+	Command.AddCommand(encryptCommand, decryptCommand, genKeyCommand, historyCommand, rollbackCommand)
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/no-mole/neptune/config"
+	"github.com/no-mole/neptune/json"
+	"github.com/spf13/cobra"
+)
+
+var clientConf = &config.Config{}
+
+var (
+	historyLimit int
+	historyJson  bool
+	author       string
+)
+
+var historyCommand = &cobra.Command{
+	Use:     "history [$key]",
+	Short:   "List change history of a config key,newest first",
+	Example: "neptune config history --type etcd --endpoints 127.0.0.1:2379 --namespace dev mysql.yaml",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		historian, closeFn, err := newHistorian(cmd.Context())
+		if err != nil {
+			return err
+		}
+		defer closeFn()
+		revisions, err := historian.History(cmd.Context(), args[0], historyLimit)
+		if err != nil {
+			return err
+		}
+		if historyJson {
+			body, err := json.Marshal(revisions)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), string(body))
+			return err
+		}
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "VERSION\tTIME\tAUTHOR\tCOMMENT\tVALUE")
+		for _, r := range revisions {
+			t := ""
+			if !r.Time.IsZero() {
+				t = r.Time.Format(time.RFC3339)
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Version, t, r.Author, r.Comment, abbreviate(r.Value))
+		}
+		return w.Flush()
+	},
+}
+
+var rollbackCommand = &cobra.Command{
+	Use:     "rollback [$key] [$version]",
+	Short:   "Roll back a config key to the value of a history version",
+	Example: "neptune config rollback --type etcd --endpoints 127.0.0.1:2379 --namespace dev mysql.yaml 1024",
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		historian, closeFn, err := newHistorian(cmd.Context())
+		if err != nil {
+			return err
+		}
+		defer closeFn()
+		err = historian.Rollback(config.WithAuthor(cmd.Context(), author), args[0], args[1])
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(cmd.OutOrStdout(), "%s rolled back to version %s\n", args[0], args[1])
+		return err
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{historyCommand, rollbackCommand} {
+		cmd.Flags().StringVar(&clientConf.Type, "type", "etcd", "config center type")
+		cmd.Flags().StringVar(&clientConf.Endpoints, "endpoints", "", "config center endpoints")
+		cmd.Flags().StringVar(&clientConf.Namespace, "namespace", "", "config center namespace")
+		cmd.Flags().StringVar(&clientConf.Username, "username", "", "config center username")
+		cmd.Flags().StringVar(&clientConf.Password, "password", "", "config center password")
+		cmd.Flags().StringToStringVar(&clientConf.Settings, "settings", map[string]string{}, "config center settings,such as group=DEFAULT_GROUP")
+	}
+	historyCommand.Flags().IntVar(&historyLimit, "limit", config.DefaultHistoryLimit, "max number of versions")
+	historyCommand.Flags().BoolVar(&historyJson, "json", false, "print versions as json with full values")
+	rollbackCommand.Flags().StringVar(&author, "author", os.Getenv("USER"), "author recorded for the rollback")
+}
+
+func newHistorian(ctx context.Context) (config.Historian, func(), error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	client, err := config.GetClientImplementation(ctx, clientConf.Type)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = client.Init(ctx, clientConf)
+	if err != nil {
+		return nil, nil, err
+	}
+	historian, ok := config.AsHistorian(client)
+	if !ok {
+		_ = client.Close()
+		return nil, nil, fmt.Errorf("config center type %s: %w", clientConf.Type, config.ErrHistoryNotSupported)
+	}
+	return historian, func() { _ = client.Close() }, nil
+}
+
+// abbreviate first line of value,at most 60 characters
+func abbreviate(value string) string {
+	line, _, multiline := strings.Cut(value, "\n")
+	if len(line) > 60 {
+		return line[:57] + "..."
+	}
+	if multiline {
+		return line + " ..."
+	}
+	return line
+}
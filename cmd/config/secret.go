@@ -0,0 +1,91 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/no-mole/neptune/config"
+	"github.com/spf13/cobra"
+)
+
+var encryptCommand = &cobra.Command{
+	Use:     "encrypt [$value]",
+	Short:   "Encrypt a value into the ENC(...) envelope decrypted transparently by config clients",
+	Example: "neptune config encrypt 'p@ssw0rd' OR echo -n 'p@ssw0rd' | neptune config encrypt",
+	Args:    cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		value, err := readValue(cmd, args)
+		if err != nil {
+			return err
+		}
+		encrypted, err := config.EncryptValue(cmd.Context(), cipher(), value)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(cmd.OutOrStdout(), encrypted)
+		return err
+	},
+}
+
+var decryptCommand = &cobra.Command{
+	Use:   "decrypt [$value]",
+	Short: "Decrypt ENC(...) envelopes of a value",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		value, err := readValue(cmd, args)
+		if err != nil {
+			return err
+		}
+		plain, err := config.DecryptValue(cmd.Context(), cipher(), value)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(cmd.OutOrStdout(), plain)
+		return err
+	},
+}
+
+var genKeySize int
+
+var genKeyCommand = &cobra.Command{
+	Use:   "gen-key",
+	Short: "Generate a random base64 encoded aes key",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if genKeySize != 16 && genKeySize != 24 && genKeySize != 32 {
+			return errors.New("key size must be 16, 24 or 32")
+		}
+		key := make([]byte, genKeySize)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintln(cmd.OutOrStdout(), base64.StdEncoding.EncodeToString(key))
+		return err
+	},
+}
+
+func init() {
+	genKeyCommand.Flags().IntVar(&genKeySize, "size", 32, "key size in bytes,16, 24 or 32")
+}
+
+func cipher() config.Cipher {
+	if keyFile != "" {
+		return config.NewAesCipher(config.FileKeyProvider(keyFile))
+	}
+	return config.NewAesCipher(config.EnvKeyProvider(keyEnv))
+}
+
+// readValue value from args,or stdin without the trailing newline
+func readValue(cmd *cobra.Command, args []string) (string, error) {
+	if len(args) == 1 {
+		return args[0], nil
+	}
+	body, err := io.ReadAll(cmd.InOrStdin())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(body), "\r\n"), nil
+}
@@ -17,6 +17,9 @@ func Dialer(_ context.Context) application.HookFunc {
 			grpc.WithTransportCredentials(insecure.NewCredentials()),
 			middleware.OtelGrpcUnaryClientInterceptor(),
 			middleware.OtelGrpcStreamClientInterceptor(),
+			middleware.MetricsGrpcUnaryClientInterceptor(),
+			middleware.MetricsGrpcStreamClientInterceptor(),
+			grpc_service.WithWeightedBalancer(),
 		}
 		return grpc_dialer.DialContext(
 			ctx,
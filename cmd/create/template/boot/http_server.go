@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/gin-gonic/gin"
 	"github.com/no-mole/neptune/application"
+	middleware "github.com/no-mole/neptune/middlewares"
 	"github.com/no-mole/neptune/server"
 	"net/http"
 )
@@ -12,6 +13,7 @@ func HttpServer(_ context.Context) application.Plugin {
 	handleFn := func(ctx context.Context) http.Handler {
 		gin.SetMode(gin.ReleaseMode)
 		ginEngine := gin.New()
+		ginEngine.Use(middleware.GinMetrics())
 		return ginEngine
 	}
 	return server.NewHttpServerPlugin(handleFn)
@@ -0,0 +1,210 @@
+package registry
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/no-mole/neptune/config"
+	"github.com/no-mole/neptune/grpc_service"
+	"github.com/spf13/cobra"
+)
+
+var Command = &cobra.Command{
+	Use:   "registry",
+	Short: "Inspect and manage services registered by neptune grpc servers",
+}
+
+var (
+	clientConf    = &config.Config{}
+	listJson      bool
+	watchInterval time.Duration
+)
+
+// Endpoint a registered endpoint of service
+type Endpoint struct {
+	Service      string                 `json:"service"` //unique key of service metadata
+	Endpoint     string                 `json:"endpoint"`
+	DiscoveryKey string                 `json:"discoveryKey"`
+	Instance     *grpc_service.Instance `json:"instance"`
+	Healthy      bool                   `json:"healthy"`
+	Lease        string                 `json:"lease,omitempty"`
+	TTL          int64                  `json:"ttl,omitempty"` //remaining seconds of etcd lease
+	GrantedTTL   int64                  `json:"grantedTtl,omitempty"`
+}
+
+type registry interface {
+	// List endpoints of services with unique key prefix,sorted by service and endpoint
+	List(ctx context.Context, prefix string) ([]*Endpoint, error)
+	// Watch call fn with PUT or DELETE on changes of endpoints until ctx done
+	Watch(ctx context.Context, prefix string, fn func(action string, ep *Endpoint)) error
+	// Deregister remove endpoint of service from registry
+	Deregister(ctx context.Context, service, endpoint string) error
+	Close() error
+}
+
+var registryTypes = map[string]func(ctx context.Context, conf *config.Config) (registry, error){
+	"etcd":  newEtcdRegistry,
+	"nacos": newNacosRegistry,
+}
+
+var listCommand = &cobra.Command{
+	Use:     "list [$service-prefix]",
+	Short:   "List registered services and endpoints of namespace",
+	Example: "neptune registry list --type etcd --endpoints 127.0.0.1:2379 --namespace dev /zeus",
+	Args:    cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withRegistry(cmd, func(ctx context.Context, r registry) error {
+			endpoints, err := r.List(ctx, servicePrefix(args))
+			if err != nil {
+				return err
+			}
+			if listJson {
+				return printJson(cmd, endpoints)
+			}
+			return printTable(cmd.OutOrStdout(), endpoints)
+		})
+	},
+}
+
+var inspectCommand = &cobra.Command{
+	Use:     "inspect [$service] [$endpoint]",
+	Short:   "Show registration metadata and lease of endpoints of a service",
+	Example: "neptune registry inspect --type etcd --endpoints 127.0.0.1:2379 --namespace dev /zeus/zeus.proto/zeus.ZeusService/v1 10.0.0.1:8080",
+	Args:    cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withRegistry(cmd, func(ctx context.Context, r registry) error {
+			service := servicePrefix(args)
+			endpoints, err := r.List(ctx, service)
+			if err != nil {
+				return err
+			}
+			var matched []*Endpoint
+			for _, ep := range endpoints {
+				if ep.Service == service && (len(args) == 1 || ep.Endpoint == args[1]) {
+					matched = append(matched, ep)
+				}
+			}
+			if len(matched) == 0 {
+				return fmt.Errorf("service [%s] %s not registered", service, strings.Join(args[1:], ""))
+			}
+			return printJson(cmd, matched)
+		})
+	},
+}
+
+var watchCommand = &cobra.Command{
+	Use:     "watch [$service-prefix]",
+	Short:   "Watch registration changes of services until interrupted",
+	Example: "neptune registry watch --type etcd --endpoints 127.0.0.1:2379 --namespace dev /zeus",
+	Args:    cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withRegistry(cmd, func(ctx context.Context, r registry) error {
+			ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+			defer stop()
+			return r.Watch(ctx, servicePrefix(args), func(action string, ep *Endpoint) {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s %-6s %s %s weight=%d zone=%s\n",
+					time.Now().Format(time.RFC3339), action, ep.Service, ep.Endpoint, ep.Instance.GetWeight(), ep.Instance.Zone)
+			})
+		})
+	},
+}
+
+var deregisterCommand = &cobra.Command{
+	Use:     "deregister [$service] [$endpoint]",
+	Short:   "Forcibly remove a stale endpoint of a service from registry",
+	Example: "neptune registry deregister --type etcd --endpoints 127.0.0.1:2379 --namespace dev /zeus/zeus.proto/zeus.ZeusService/v1 10.0.0.1:8080",
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withRegistry(cmd, func(ctx context.Context, r registry) error {
+			service := servicePrefix(args)
+			err := r.Deregister(ctx, service, args[1])
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "%s %s deregistered\n", service, args[1])
+			return err
+		})
+	},
+}
+
+func init() {
+	Command.PersistentFlags().StringVar(&clientConf.Type, "type", "etcd", "registry type,etcd or nacos")
+	Command.PersistentFlags().StringVar(&clientConf.Endpoints, "endpoints", "", "registry endpoints")
+	Command.PersistentFlags().StringVar(&clientConf.Namespace, "namespace", "", "registry namespace")
+	Command.PersistentFlags().StringVar(&clientConf.Username, "username", "", "registry username")
+	Command.PersistentFlags().StringVar(&clientConf.Password, "password", "", "registry password")
+	Command.PersistentFlags().StringToStringVar(&clientConf.Settings, "settings", map[string]string{}, "registry settings,such as groupName=DEFAULT_GROUP")
+	listCommand.Flags().BoolVar(&listJson, "json", false, "print endpoints as json")
+	watchCommand.Flags().DurationVar(&watchInterval, "interval", 5*time.Second, "poll interval of registries without watch,such as nacos")
+	Command.AddCommand(listCommand, inspectCommand, watchCommand, deregisterCommand)
+}
+
+func withRegistry(cmd *cobra.Command, fn func(ctx context.Context, r registry) error) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	newFn, ok := registryTypes[clientConf.Type]
+	if !ok {
+		return fmt.Errorf("unsupported registry type:[%s]", clientConf.Type)
+	}
+	r, err := newFn(ctx, clientConf)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = r.Close() }()
+	return fn(ctx, r)
+}
+
+// servicePrefix unique keys of service metadata start with /
+func servicePrefix(args []string) string {
+	if len(args) == 0 || args[0] == "" {
+		return "/"
+	}
+	if !strings.HasPrefix(args[0], "/") {
+		return "/" + args[0]
+	}
+	return args[0]
+}
+
+func printTable(out io.Writer, endpoints []*Endpoint) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "SERVICE\tENDPOINT\tHOSTNAME\tWEIGHT\tZONE\tVERSION\tUPTIME\tHEALTHY\tTTL")
+	for _, ep := range endpoints {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\t%t\t%s\n",
+			ep.Service, ep.Endpoint, ep.Instance.Hostname, ep.Instance.GetWeight(), ep.Instance.Zone,
+			ep.Instance.Version, uptime(ep.Instance), ep.Healthy, ttl(ep))
+	}
+	return w.Flush()
+}
+
+func printJson(cmd *cobra.Command, v any) error {
+	body, err := stdjson.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(cmd.OutOrStdout(), string(body))
+	return err
+}
+
+func uptime(instance *grpc_service.Instance) string {
+	if instance.StartTime.IsZero() {
+		return "-"
+	}
+	return time.Since(instance.StartTime).Truncate(time.Second).String()
+}
+
+func ttl(ep *Endpoint) string {
+	if ep.Lease == "" {
+		return "-"
+	}
+	return strconv.FormatInt(ep.TTL, 10) + "s/" + strconv.FormatInt(ep.GrantedTTL, 10) + "s"
+}
@@ -0,0 +1,99 @@
+package registry
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/no-mole/neptune/grpc_service"
+)
+
+func TestServicePrefix(t *testing.T) {
+	for _, c := range []struct {
+		args []string
+		want string
+	}{
+		{nil, "/"},
+		{[]string{""}, "/"},
+		{[]string{"zeus"}, "/zeus"},
+		{[]string{"/zeus/zeus.proto"}, "/zeus/zeus.proto"},
+	} {
+		if got := servicePrefix(c.args); got != c.want {
+			t.Errorf("servicePrefix(%v) should be %s,got %s", c.args, c.want, got)
+		}
+	}
+}
+
+func TestEtcdEndpoint(t *testing.T) {
+	e := &etcdRegistry{root: "/dev/"}
+	key := "/dev//zeus/zeus.proto/zeus.ZeusService/v1/10.0.0.1:8080"
+	ep, ok := e.endpoint(key, []byte(`{"hostname":"zeus-0","weight":50,"zone":"az1"}`))
+	if !ok {
+		t.Fatalf("registration key %s should be parsed", key)
+	}
+	if ep.Service != "/zeus/zeus.proto/zeus.ZeusService/v1" || ep.Endpoint != "10.0.0.1:8080" || ep.DiscoveryKey != key {
+		t.Fatalf("unexpected endpoint %+v", ep)
+	}
+	if ep.Instance.Hostname != "zeus-0" || ep.Instance.GetWeight() != 50 || ep.Instance.Zone != "az1" || !ep.Healthy {
+		t.Fatalf("unexpected instance %+v", ep.Instance)
+	}
+
+	//values of old versions only hold the hostname
+	ep, ok = e.endpoint("/dev//zeus/zeus.proto/zeus.ZeusService/v1/[::1]:8080", []byte("zeus-1"))
+	if !ok || ep.Endpoint != "[::1]:8080" || ep.Instance.Hostname != "zeus-1" || ep.Instance.GetWeight() != grpc_service.DefaultWeight {
+		t.Fatalf("unexpected endpoint of old value %+v", ep)
+	}
+
+	for _, key := range []string{
+		"/prod//zeus/zeus.proto/zeus.ZeusService/v1/10.0.0.1:8080", //other namespace
+		"/dev/mysql.yaml",                                //config key
+		"/dev//zeus/zeus.proto/10.0.0.1:8080",            //too short to be service
+		"/dev//zeus/zeus.proto/zeus.ZeusService/v1/host", //not endpoint
+	} {
+		if ep, ok := e.endpoint(key, nil); ok {
+			t.Errorf("key %s should be skipped,got %+v", key, ep)
+		}
+	}
+}
+
+func TestPrintTable(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := printTable(out, []*Endpoint{
+		{
+			Service:    "/zeus/zeus.proto/zeus.ZeusService/v1",
+			Endpoint:   "10.0.0.1:8080",
+			Instance:   &grpc_service.Instance{Hostname: "zeus-0", Weight: 50, Zone: "az1", Version: "1.2.0"},
+			Healthy:    true,
+			Lease:      "694d",
+			TTL:        7,
+			GrantedTTL: 10,
+		},
+		{
+			Service:  "/zeus/zeus.proto/zeus.ZeusService/v1",
+			Endpoint: "10.0.0.2:8080",
+			Instance: &grpc_service.Instance{Hostname: "zeus-1"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	want := [][]string{
+		{"SERVICE", "ENDPOINT", "HOSTNAME", "WEIGHT", "ZONE", "VERSION", "UPTIME", "HEALTHY", "TTL"},
+		{"/zeus/zeus.proto/zeus.ZeusService/v1", "10.0.0.1:8080", "zeus-0", "50", "az1", "1.2.0", "-", "true", "7s/10s"},
+		{"/zeus/zeus.proto/zeus.ZeusService/v1", "10.0.0.2:8080", "zeus-1", strconv.Itoa(grpc_service.DefaultWeight), "-", "false", "-"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("unexpected table\n%s", out.String())
+	}
+	for i, line := range lines {
+		if got := strings.Fields(line); strings.Join(got, " ") != strings.Join(want[i], " ") {
+			t.Errorf("line %d should be %v,got %v", i, want[i], got)
+		}
+	}
+	//columns are aligned
+	if strings.Index(lines[0], "ENDPOINT") != strings.Index(lines[1], "10.0.0.1:8080") {
+		t.Errorf("columns should be aligned\n%s", out.String())
+	}
+}
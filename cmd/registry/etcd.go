@@ -0,0 +1,121 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/no-mole/neptune/config"
+	"github.com/no-mole/neptune/grpc_service"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// etcdRegistry endpoints are registered as /{namespace}/{unique key}/{endpoint} by grpc_service.EtcdRegister
+type etcdRegistry struct {
+	client *clientv3.Client
+	root   string
+}
+
+func newEtcdRegistry(ctx context.Context, conf *config.Config) (registry, error) {
+	client, err := clientv3.New(config.Trans2EtcdConfig(ctx, conf))
+	if err != nil {
+		return nil, err
+	}
+	return &etcdRegistry{client: client, root: fmt.Sprintf("/%s/", conf.Namespace)}, nil
+}
+
+func (e *etcdRegistry) Close() error {
+	return e.client.Close()
+}
+
+func (e *etcdRegistry) List(ctx context.Context, prefix string) ([]*Endpoint, error) {
+	resp, err := e.client.Get(ctx, e.root+prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	leases := map[int64]*clientv3.LeaseTimeToLiveResponse{}
+	var endpoints []*Endpoint
+	for _, kv := range resp.Kvs {
+		ep, ok := e.endpoint(string(kv.Key), kv.Value)
+		if !ok {
+			continue
+		}
+		if kv.Lease != 0 {
+			lease, ok := leases[kv.Lease]
+			if !ok {
+				lease, err = e.client.TimeToLive(ctx, clientv3.LeaseID(kv.Lease))
+				if err != nil {
+					return nil, err
+				}
+				leases[kv.Lease] = lease
+			}
+			ep.Lease = strconv.FormatInt(kv.Lease, 16)
+			ep.TTL, ep.GrantedTTL = lease.TTL, lease.GrantedTTL
+		}
+		endpoints = append(endpoints, ep)
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i].Service != endpoints[j].Service {
+			return endpoints[i].Service < endpoints[j].Service
+		}
+		return endpoints[i].Endpoint < endpoints[j].Endpoint
+	})
+	return endpoints, nil
+}
+
+func (e *etcdRegistry) Watch(ctx context.Context, prefix string, fn func(action string, ep *Endpoint)) error {
+	for resp := range e.client.Watch(ctx, e.root+prefix, clientv3.WithPrefix(), clientv3.WithPrevKV()) {
+		if err := resp.Err(); err != nil {
+			return err
+		}
+		for _, event := range resp.Events {
+			value := event.Kv.Value
+			if event.Type == clientv3.EventTypeDelete && event.PrevKv != nil {
+				value = event.PrevKv.Value
+			}
+			ep, ok := e.endpoint(string(event.Kv.Key), value)
+			if !ok {
+				continue
+			}
+			fn(event.Type.String(), ep)
+		}
+	}
+	return nil
+}
+
+func (e *etcdRegistry) Deregister(ctx context.Context, service, endpoint string) error {
+	key := e.root + service + "/" + endpoint
+	resp, err := e.client.Delete(ctx, key)
+	if err != nil {
+		return err
+	}
+	if resp.Deleted == 0 {
+		return fmt.Errorf("registration [%s] not found", key)
+	}
+	return nil
+}
+
+// endpoint parse registration key,keys of config or other data in namespace are skipped
+func (e *etcdRegistry) endpoint(key string, value []byte) (*Endpoint, bool) {
+	rest, ok := strings.CutPrefix(key, e.root)
+	if !ok {
+		return nil, false
+	}
+	i := strings.LastIndex(rest, "/")
+	if i <= 0 || strings.Count(rest[:i], "/") < 3 {
+		return nil, false
+	}
+	if _, _, err := net.SplitHostPort(rest[i+1:]); err != nil {
+		return nil, false
+	}
+	return &Endpoint{
+		Service:      rest[:i],
+		Endpoint:     rest[i+1:],
+		DiscoveryKey: key,
+		Instance:     grpc_service.DecodeInstance(value),
+		Healthy:      true,
+	}, true
+}
@@ -0,0 +1,159 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nacos-group/nacos-sdk-go/v2/clients"
+	"github.com/nacos-group/nacos-sdk-go/v2/clients/naming_client"
+	"github.com/nacos-group/nacos-sdk-go/v2/common/constant"
+	"github.com/nacos-group/nacos-sdk-go/v2/vo"
+	"github.com/no-mole/neptune/config"
+	"github.com/no-mole/neptune/grpc_service"
+)
+
+// nacosRegistry endpoints are registered as instances of service named by unique key by grpc_service.NacosRegister
+type nacosRegistry struct {
+	client    naming_client.INamingClient
+	namespace string
+	groupName string
+}
+
+func newNacosRegistry(ctx context.Context, conf *config.Config) (registry, error) {
+	clientConfig, serverConfigs, err := config.Trans2NacosConfig(ctx, conf)
+	if err != nil {
+		return nil, err
+	}
+	client, err := clients.NewNamingClient(vo.NacosClientParam{
+		ClientConfig:  clientConfig,
+		ServerConfigs: serverConfigs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	groupName := conf.Settings["groupName"]
+	if groupName == "" {
+		groupName = constant.DEFAULT_GROUP
+	}
+	return &nacosRegistry{client: client, namespace: conf.Namespace, groupName: groupName}, nil
+}
+
+func (n *nacosRegistry) Close() error {
+	n.client.CloseClient()
+	return nil
+}
+
+func (n *nacosRegistry) List(_ context.Context, prefix string) ([]*Endpoint, error) {
+	var services []string
+	for pageNo := uint32(1); ; pageNo++ {
+		page, err := n.client.GetAllServicesInfo(vo.GetAllServiceInfoParam{
+			NameSpace: n.namespace,
+			GroupName: n.groupName,
+			PageNo:    pageNo,
+			PageSize:  100,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, service := range page.Doms {
+			if strings.HasPrefix(service, prefix) {
+				services = append(services, service)
+			}
+		}
+		if len(page.Doms) == 0 || int64(pageNo)*100 >= page.Count {
+			break
+		}
+	}
+	sort.Strings(services)
+	var endpoints []*Endpoint
+	for _, service := range services {
+		instances, err := n.client.SelectAllInstances(vo.SelectAllInstancesParam{
+			ServiceName: service,
+			GroupName:   n.groupName,
+		})
+		if err != nil {
+			return nil, err
+		}
+		var serviceEndpoints []*Endpoint
+		for _, instance := range instances {
+			endpoint := net.JoinHostPort(instance.Ip, strconv.FormatUint(instance.Port, 10))
+			serviceEndpoints = append(serviceEndpoints, &Endpoint{
+				Service:      service,
+				Endpoint:     endpoint,
+				DiscoveryKey: fmt.Sprintf("%s@@%s/%s", n.groupName, service, endpoint),
+				Instance:     grpc_service.InstanceFromNacos(instance.Weight, instance.Metadata),
+				Healthy:      instance.Healthy && instance.Enable,
+			})
+		}
+		sort.Slice(serviceEndpoints, func(i, j int) bool {
+			return serviceEndpoints[i].Endpoint < serviceEndpoints[j].Endpoint
+		})
+		endpoints = append(endpoints, serviceEndpoints...)
+	}
+	return endpoints, nil
+}
+
+// Watch nacos subscriptions are per service,so services are listed every watch interval to catch new ones
+func (n *nacosRegistry) Watch(ctx context.Context, prefix string, fn func(action string, ep *Endpoint)) error {
+	known := map[string]*Endpoint{}
+	fingerprint := func(ep *Endpoint) string {
+		return ep.Instance.Encode() + strconv.FormatBool(ep.Healthy)
+	}
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		endpoints, err := n.List(ctx, prefix)
+		if err != nil {
+			return err
+		}
+		current := make(map[string]*Endpoint, len(endpoints))
+		for _, ep := range endpoints {
+			key := ep.Service + " " + ep.Endpoint
+			current[key] = ep
+			if old, ok := known[key]; !ok || fingerprint(old) != fingerprint(ep) {
+				fn("PUT", ep)
+			}
+		}
+		for key, ep := range known {
+			if _, ok := current[key]; !ok {
+				fn("DELETE", ep)
+			}
+		}
+		known = current
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (n *nacosRegistry) Deregister(_ context.Context, service, endpoint string) error {
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return err
+	}
+	portInt, err := strconv.ParseUint(port, 10, 64)
+	if err != nil {
+		return err
+	}
+	success, err := n.client.DeregisterInstance(vo.DeregisterInstanceParam{
+		Ip:          host,
+		Port:        portInt,
+		ServiceName: service,
+		GroupName:   n.groupName,
+		Ephemeral:   true,
+	})
+	if err != nil {
+		return err
+	}
+	if !success {
+		return fmt.Errorf("nacos deregister: service [%s] endpoint %s unsuccess", service, endpoint)
+	}
+	return nil
+}
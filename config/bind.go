@@ -0,0 +1,199 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	validate "github.com/go-playground/validator/v10"
+	"github.com/no-mole/neptune/json"
+	"github.com/no-mole/neptune/logger"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+var validator = validate.New()
+
+// Codec decode config value
+type Codec interface {
+	Unmarshal(data []byte, v any) error
+}
+
+// CodecFunc adapter to allow the use of ordinary functions as Codec
+type CodecFunc func(data []byte, v any) error
+
+func (f CodecFunc) Unmarshal(data []byte, v any) error {
+	return f(data, v)
+}
+
+var (
+	CodecJson Codec = CodecFunc(json.Unmarshal)
+	CodecYaml Codec = CodecFunc(yaml.Unmarshal)
+	CodecToml Codec = CodecFunc(toml.Unmarshal)
+
+	codecs = map[string]Codec{
+		".json": CodecJson,
+		".yaml": CodecYaml,
+		".yml":  CodecYaml,
+		".toml": CodecToml,
+	}
+)
+
+// RegisterCodec register codec for keys with the extension,such as .ini
+func RegisterCodec(ext string, codec Codec) {
+	codecs[ext] = codec
+}
+
+// CodecByKey codec chosen by key extension,json is used for keys without known extension
+func CodecByKey(key string) Codec {
+	if codec, ok := codecs[strings.ToLower(path.Ext(key))]; ok {
+		return codec
+	}
+	return CodecJson
+}
+
+type bindOptions struct {
+	client Client
+	codec  Codec
+}
+
+type BindOption func(o *bindOptions)
+
+// WithBindClient bind value from client instead of the default client
+func WithBindClient(client Client) BindOption {
+	return func(o *bindOptions) {
+		o.client = client
+	}
+}
+
+// WithCodec decode value by codec instead of the codec chosen by key extension
+func WithCodec(codec Codec) BindOption {
+	return func(o *bindOptions) {
+		o.codec = codec
+	}
+}
+
+// Binding value of key decoded into T,updated atomically when the key changed.
+// Invalid updates are rejected and the last good value is kept
+type Binding[T any] struct {
+	key   string
+	codec Codec
+
+	value   atomic.Pointer[T]
+	lastErr atomic.Pointer[error]
+
+	mu          sync.Mutex
+	subscribers map[int]func(old, new *T)
+	nextId      int
+}
+
+// Bind fetch key,decode and validate it into T,then watch the key until ctx is done.
+// T is validated by its Validate() error method if implemented,otherwise by validate tags of struct
+func Bind[T any](ctx context.Context, key string, opts ...BindOption) (*Binding[T], error) {
+	o := &bindOptions{client: defaultClient}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.client == nil {
+		return nil, errors.New("config client not initialized")
+	}
+	if o.codec == nil {
+		o.codec = CodecByKey(key)
+	}
+	b := &Binding[T]{
+		key:         key,
+		codec:       o.codec,
+		subscribers: map[int]func(old, new *T){},
+	}
+	item, err := o.client.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	value, err := b.decode(item.GetValue())
+	if err != nil {
+		return nil, err
+	}
+	b.value.Store(value)
+	err = o.client.Watch(ctx, item, func(item *Item) {
+		b.update(ctx, item.GetValue())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Load current value,callers must not modify it
+func (b *Binding[T]) Load() *T {
+	return b.value.Load()
+}
+
+// Err error of the last rejected update,nil after a successful update
+func (b *Binding[T]) Err() error {
+	if err := b.lastErr.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+// Subscribe fn is called with the old and new value after each accepted update,returns a func to unsubscribe
+func (b *Binding[T]) Subscribe(fn func(old, new *T)) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.nextId
+	b.nextId++
+	b.subscribers[id] = fn
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers, id)
+	}
+}
+
+func (b *Binding[T]) update(ctx context.Context, body string) {
+	value, err := b.decode(body)
+	if err != nil {
+		b.lastErr.Store(&err)
+		logger.Error(ctx, "config binding rejected update", err, logger.WithField("key", b.key))
+		return
+	}
+	b.lastErr.Store(nil)
+	//hold the lock while notifying so that subscribers see updates in order
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	old := b.value.Swap(value)
+	for _, fn := range b.subscribers {
+		fn(old, value)
+	}
+}
+
+func (b *Binding[T]) decode(body string) (*T, error) {
+	if body == "" {
+		return nil, fmt.Errorf("config key [%s] is empty", b.key)
+	}
+	value := new(T)
+	err := b.codec.Unmarshal([]byte(body), value)
+	if err != nil {
+		return nil, fmt.Errorf("decode config key [%s]: %w", b.key, err)
+	}
+	err = validateValue(value)
+	if err != nil {
+		return nil, fmt.Errorf("validate config key [%s]: %w", b.key, err)
+	}
+	return value, nil
+}
+
+func validateValue(value any) error {
+	if v, ok := value.(interface{ Validate() error }); ok {
+		return v.Validate()
+	}
+	if reflect.TypeOf(value).Elem().Kind() == reflect.Struct {
+		return validator.Struct(value)
+	}
+	return nil
+}
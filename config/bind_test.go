@@ -0,0 +1,41 @@
+package config
+
+import (
+	"context"
+	"testing"
+)
+
+type bindConf struct {
+	Addr string `yaml:"addr" validate:"required"`
+	Size int    `yaml:"size"`
+}
+
+func TestBind(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cli := NewMemoryConfigClient(map[string]string{"redis.yaml": "addr: localhost:6379"})
+	b, err := Bind[bindConf](ctx, "redis.yaml", WithBindClient(cli))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Load().Addr != "localhost:6379" {
+		t.Fatalf("unexpected value %+v", b.Load())
+	}
+	var notified *bindConf
+	b.Subscribe(func(old, new *bindConf) {
+		notified = new
+	})
+	_ = cli.Set(ctx, "redis.yaml", "addr: redis:6379\nsize: 10")
+	if notified == nil || notified.Size != 10 || b.Load().Addr != "redis:6379" {
+		t.Fatalf("update not applied %+v", b.Load())
+	}
+	//invalid update is rejected
+	_ = cli.Set(ctx, "redis.yaml", "size: 20")
+	if b.Err() == nil || b.Load().Addr != "redis:6379" {
+		t.Fatalf("invalid update should keep last good value,got %+v", b.Load())
+	}
+	_, err = Bind[bindConf](ctx, "missing.json", WithBindClient(cli))
+	if err == nil {
+		t.Fatal("expect empty key error")
+	}
+}
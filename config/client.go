@@ -15,14 +15,14 @@ func RegistryImplementation(typeName string, fn RegistryImplementationFunc) {
 }
 
 type Config struct {
-	Type      string `yaml:"type" json:"type"`
-	Endpoints string `yaml:"endpoints" json:"endpoints"`
-	Namespace string `yaml:"namespace" json:"namespace"`
+	Type      string `yaml:"type" json:"type" flag:"type" usage:"config client type" validate:"required"`
+	Endpoints string `yaml:"endpoints" json:"endpoints" flag:"endpoints" usage:"config client endpoints"`
+	Namespace string `yaml:"namespace" json:"namespace" flag:"namespace" usage:"config client namespace"`
 
-	Username string `yaml:"username" json:"userName"`
-	Password string `yaml:"password" json:"password"`
+	Username string `yaml:"username" json:"userName" flag:"username" usage:"config client username"`
+	Password string `yaml:"password" json:"password" flag:"password" usage:"config client password"`
 
-	Settings map[string]string `yaml:"settings" json:"settings"`
+	Settings map[string]string `yaml:"settings" json:"settings" flag:"settings" usage:"config client settings"`
 }
 
 type Client interface {
@@ -32,6 +32,33 @@ type Client interface {
 	Get(ctx context.Context, key string) (*Item, error)
 	Watch(ctx context.Context, item *Item, callback func(item *Item)) error
 	Exist(ctx context.Context, key string) (bool, error)
+
+	// List items with key prefix,keys of items are relative to namespace
+	List(ctx context.Context, prefix string) ([]*Item, error)
+	// Delete key,deleting a not exist key is not an error
+	Delete(ctx context.Context, key string) error
+	// WatchPrefix callback is called with put and delete events of keys with prefix until ctx is done
+	WatchPrefix(ctx context.Context, prefix string, callback func(event *Event)) error
+	// CompareAndSet set value only when the revision of key is unchanged,
+	// empty revision means the key must not exist.returns false when the revision not matched
+	CompareAndSet(ctx context.Context, key, value, revision string) (bool, error)
+}
+
+type EventType string
+
+const (
+	EventPut    EventType = "put"
+	EventDelete EventType = "delete"
+)
+
+type Event struct {
+	Type EventType
+	Item *Item
+}
+
+// Pinger is implemented by clients able to check the connectivity to config center
+type Pinger interface {
+	Ping(ctx context.Context) error
 }
 
 // GetClientImplementation get client implementation by typeName
@@ -55,13 +82,46 @@ type Item struct {
 	Namespace     string `json:"namespace"`
 	Key           string `json:"key"`
 	value         string
+	revision      string
+	deleted       bool
 	*sync.RWMutex `json:"-"`
 }
 
+// SetRevision set revision of value,etcd mod revision or md5 of value for other clients
+func (i *Item) SetRevision(revision string) {
+	i.Lock()
+	defer i.Unlock()
+	i.revision = revision
+}
+
+// GetRevision revision of value used by CompareAndSet
+func (i *Item) GetRevision() string {
+	i.RLock()
+	defer i.RUnlock()
+	return i.revision
+}
+
 func (i *Item) SetValue(value string) {
 	i.Lock()
 	defer i.Unlock()
 	i.value = value
+	i.deleted = false
+}
+
+// SetDeleted mark the key deleted,the value is cleared
+func (i *Item) SetDeleted() {
+	i.Lock()
+	defer i.Unlock()
+	i.value = ""
+	i.revision = ""
+	i.deleted = true
+}
+
+// IsDeleted the key was deleted,distinct from an empty value
+func (i *Item) IsDeleted() bool {
+	i.RLock()
+	defer i.RUnlock()
+	return i.deleted
 }
 
 func (i *Item) GetValue() string {
@@ -72,13 +132,26 @@ func (i *Item) GetValue() string {
 
 var defaultClient Client
 
+// InitDefaultClient init client of config.Type,with snapshot_dir setting fetched values are persisted
+// and served when the config center is unreachable,see NewSnapshotClient
 func InitDefaultClient(ctx context.Context, config *Config) error {
 	impl, err := GetClientImplementation(ctx, config.Type)
 	if err != nil {
 		return err
 	}
-	defaultClient = impl
-	return impl.Init(ctx, config)
+	if dir := config.Settings["snapshot_dir"]; dir != "" {
+		//impl is wrapped,fn creates clients to reconnect only
+		snapshot := NewSnapshotClient(configCenterImplementation[config.Type], dir)
+		snapshot.initial = impl
+		impl = snapshot
+	}
+	err = impl.Init(ctx, config)
+	if err != nil {
+		return err
+	}
+	//ENC(...) values are decrypted transparently,an error is returned only when reading them without key
+	defaultClient = NewSecretClient(impl, CipherFromConfig(config))
+	return nil
 }
 
 func Set(ctx context.Context, key, value string) error {
@@ -94,6 +167,19 @@ func Exist(ctx context.Context, key string) (bool, error) {
 	return defaultClient.Exist(ctx, key)
 }
 
+func List(ctx context.Context, prefix string) ([]*Item, error) {
+	return defaultClient.List(ctx, prefix)
+}
+func Delete(ctx context.Context, key string) error {
+	return defaultClient.Delete(ctx, key)
+}
+func WatchPrefix(ctx context.Context, prefix string, callback func(event *Event)) error {
+	return defaultClient.WatchPrefix(ctx, prefix, callback)
+}
+func CompareAndSet(ctx context.Context, key, value, revision string) (bool, error) {
+	return defaultClient.CompareAndSet(ctx, key, value, revision)
+}
+
 func Close() error {
 	return defaultClient.Close()
 }
@@ -0,0 +1,267 @@
+package config
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/no-mole/neptune/logger"
+)
+
+var RegistryImplementationTypeNameConsul = "consul"
+
+func init() {
+	RegistryImplementation(RegistryImplementationTypeNameConsul, func(ctx context.Context) Client {
+		return &ConsulConfigClient{}
+	})
+}
+
+var (
+	_ Client = &ConsulConfigClient{} //ensure ConsulConfigClient Implementation Client
+)
+
+// ConsulWaitTime max wait time of consul blocking queries used by watches
+var ConsulWaitTime = time.Minute
+
+// ConsulConfigClient values are stored in consul kv as {namespace}/{key},
+// revision is the modify index of key
+type ConsulConfigClient struct {
+	config    *Config
+	client    *consulapi.Client
+	closeCh   chan struct{}
+	closeOnce sync.Once
+}
+
+func (s *ConsulConfigClient) Init(ctx context.Context, conf *Config) error {
+	s.config = conf
+	s.closeCh = make(chan struct{})
+	cli, err := consulapi.NewClient(Trans2ConsulConfig(ctx, conf))
+	if err != nil {
+		return err
+	}
+	s.client = cli
+	return nil
+}
+
+// Close stop watches,consul client has no connection to close
+func (s *ConsulConfigClient) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.closeCh)
+	})
+	return nil
+}
+
+func (s *ConsulConfigClient) Set(ctx context.Context, key, value string) error {
+	_, err := s.client.KV().Put(&consulapi.KVPair{Key: s.genKey(key), Value: []byte(value)}, s.writeOptions(ctx))
+	return err
+}
+
+func (s *ConsulConfigClient) Get(ctx context.Context, key string) (*Item, error) {
+	pair, _, err := s.client.KV().Get(s.genKey(key), s.queryOptions(ctx, 0))
+	if err != nil {
+		return nil, err
+	}
+	if pair == nil {
+		return NewItem(s.config.Namespace, key, ""), nil
+	}
+	return s.item(pair), nil
+}
+
+// List folder keys ending with / are skipped
+func (s *ConsulConfigClient) List(ctx context.Context, prefix string) ([]*Item, error) {
+	pairs, _, err := s.client.KV().List(s.genKey(prefix), s.queryOptions(ctx, 0))
+	if err != nil {
+		return nil, err
+	}
+	return s.items(pairs), nil
+}
+
+func (s *ConsulConfigClient) Delete(ctx context.Context, key string) error {
+	_, err := s.client.KV().Delete(s.genKey(key), s.writeOptions(ctx))
+	return err
+}
+
+// CompareAndSet consul check-and-set on modify index,index 0 puts only when key not exist
+func (s *ConsulConfigClient) CompareAndSet(ctx context.Context, key, value, revision string) (bool, error) {
+	var index uint64
+	if revision != "" {
+		var err error
+		index, err = strconv.ParseUint(revision, 10, 64)
+		if err != nil {
+			return false, err
+		}
+	}
+	ok, _, err := s.client.KV().CAS(&consulapi.KVPair{Key: s.genKey(key), Value: []byte(value), ModifyIndex: index}, s.writeOptions(ctx))
+	return ok, err
+}
+
+func (s *ConsulConfigClient) Exist(ctx context.Context, key string) (bool, error) {
+	pair, _, err := s.client.KV().Get(s.genKey(key), s.queryOptions(ctx, 0))
+	if err != nil {
+		return false, err
+	}
+	return pair != nil, nil
+}
+
+// Ping check connectivity by asking the raft leader
+func (s *ConsulConfigClient) Ping(_ context.Context) error {
+	_, err := s.client.Status().Leader()
+	return err
+}
+
+// Watch blocking queries on key,deletes are delivered with item.IsDeleted() true
+func (s *ConsulConfigClient) Watch(ctx context.Context, item *Item, callback func(item *Item)) error {
+	if callback == nil {
+		return nil
+	}
+	_, _, err := s.client.KV().Get(s.genKey(item.Key), s.queryOptions(ctx, 0))
+	if err != nil {
+		return err
+	}
+	ctx = s.closeContext(ctx)
+	//start from index 0 to compare with the revision of item,changes after item fetched are not missed
+	go s.watch(ctx, item.Key, 0, func(index uint64) (uint64, error) {
+		pair, meta, err := s.client.KV().Get(s.genKey(item.Key), s.queryOptions(ctx, index))
+		if err != nil {
+			return index, err
+		}
+		if pair == nil {
+			//items of absent keys have no revision
+			if item.GetRevision() != "" {
+				item.SetDeleted()
+				callback(item)
+			}
+		} else if revision := strconv.FormatUint(pair.ModifyIndex, 10); revision != item.GetRevision() {
+			item.SetValue(string(pair.Value))
+			item.SetRevision(revision)
+			callback(item)
+		}
+		return meta.LastIndex, nil
+	})
+	return nil
+}
+
+func (s *ConsulConfigClient) WatchPrefix(ctx context.Context, prefix string, callback func(event *Event)) error {
+	if callback == nil {
+		return nil
+	}
+	pairs, meta, err := s.client.KV().List(s.genKey(prefix), s.queryOptions(ctx, 0))
+	if err != nil {
+		return err
+	}
+	known := map[string]string{}
+	for _, item := range s.items(pairs) {
+		known[item.Key] = item.GetRevision()
+	}
+	ctx = s.closeContext(ctx)
+	go s.watch(ctx, prefix, meta.LastIndex, func(index uint64) (uint64, error) {
+		pairs, meta, err := s.client.KV().List(s.genKey(prefix), s.queryOptions(ctx, index))
+		if err != nil {
+			return index, err
+		}
+		current := map[string]string{}
+		for _, item := range s.items(pairs) {
+			current[item.Key] = item.GetRevision()
+			if revision, ok := known[item.Key]; !ok || revision != item.GetRevision() {
+				callback(&Event{Type: EventPut, Item: item})
+			}
+		}
+		for key := range known {
+			if _, ok := current[key]; !ok {
+				deleted := NewItem(s.config.Namespace, key, "")
+				deleted.SetDeleted()
+				callback(&Event{Type: EventDelete, Item: deleted})
+			}
+		}
+		known = current
+		return meta.LastIndex, nil
+	})
+	return nil
+}
+
+// watch run blocking query fn from index until ctx is done or the client closed,
+// index is reset when it goes backwards such as after a consul snapshot restore
+func (s *ConsulConfigClient) watch(ctx context.Context, key string, index uint64, fn func(index uint64) (uint64, error)) {
+	for {
+		select {
+		case <-s.closeCh:
+			return
+		case <-ctx.Done():
+			return
+		default:
+		}
+		newIndex, err := fn(index)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Error(ctx, "consul config watch", err, logger.WithField("key", key))
+			select {
+			case <-time.After(WatchRetryInterval):
+			case <-s.closeCh:
+				return
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+		if newIndex < index {
+			newIndex = 0
+		}
+		index = newIndex
+	}
+}
+
+func (s *ConsulConfigClient) queryOptions(ctx context.Context, waitIndex uint64) *consulapi.QueryOptions {
+	opts := &consulapi.QueryOptions{WaitIndex: waitIndex}
+	if waitIndex > 0 {
+		opts.WaitTime = ConsulWaitTime
+	}
+	return opts.WithContext(ctx)
+}
+
+func (s *ConsulConfigClient) writeOptions(ctx context.Context) *consulapi.WriteOptions {
+	return (&consulapi.WriteOptions{}).WithContext(ctx)
+}
+
+// closeContext ctx canceled when the client closed,to interrupt blocking queries of watches
+func (s *ConsulConfigClient) closeContext(ctx context.Context) context.Context {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-s.closeCh:
+		case <-ctx.Done():
+		}
+		cancel()
+	}()
+	return ctx
+}
+
+func (s *ConsulConfigClient) items(pairs consulapi.KVPairs) []*Item {
+	items := make([]*Item, 0, len(pairs))
+	for _, pair := range pairs {
+		if strings.HasSuffix(pair.Key, "/") {
+			continue
+		}
+		items = append(items, s.item(pair))
+	}
+	return items
+}
+
+// item convert pair to item with key relative to namespace
+func (s *ConsulConfigClient) item(pair *consulapi.KVPair) *Item {
+	item := NewItem(s.config.Namespace, strings.TrimPrefix(pair.Key, s.genKey("")), string(pair.Value))
+	item.SetRevision(strconv.FormatUint(pair.ModifyIndex, 10))
+	return item
+}
+
+// genKey consul keys must not start with /
+func (s *ConsulConfigClient) genKey(key string) string {
+	if s.config.Namespace == "" {
+		return key
+	}
+	return s.config.Namespace + "/" + key
+}
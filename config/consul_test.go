@@ -0,0 +1,187 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/no-mole/neptune/json"
+)
+
+// fakeConsulKv consul kv http api with blocking queries
+type fakeConsulKv struct {
+	mu      sync.Mutex
+	index   uint64
+	values  map[string]*fakeConsulPair
+	changed chan struct{}
+}
+
+type fakeConsulPair struct {
+	Key         string
+	Value       []byte
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+func newFakeConsulKv() *httptest.Server {
+	f := &fakeConsulKv{index: 1, values: map[string]*fakeConsulPair{}, changed: make(chan struct{})}
+	return httptest.NewServer(f)
+}
+
+func (f *fakeConsulKv) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Consul-LastContact", "0")
+	w.Header().Set("X-Consul-KnownLeader", "true")
+	if r.URL.Path == "/v1/status/leader" {
+		_, _ = w.Write([]byte(`"127.0.0.1:8300"`))
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	query := r.URL.Query()
+	switch r.Method {
+	case http.MethodGet:
+		if index, _ := strconv.ParseUint(query.Get("index"), 10, 64); index > 0 {
+			f.mu.Lock()
+			current, changed := f.index, f.changed
+			f.mu.Unlock()
+			if index >= current {
+				select {
+				case <-changed:
+				case <-time.After(time.Second):
+				case <-r.Context().Done():
+					return
+				}
+			}
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		var pairs []*fakeConsulPair
+		for k, pair := range f.values {
+			if k == key || (query.Has("recurse") && strings.HasPrefix(k, key)) {
+				pairs = append(pairs, pair)
+			}
+		}
+		sort.Slice(pairs, func(i, j int) bool { return pairs[i].Key < pairs[j].Key })
+		w.Header().Set("X-Consul-Index", strconv.FormatUint(f.index, 10))
+		if len(pairs) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, _ := json.Marshal(pairs)
+		_, _ = w.Write(body)
+	case http.MethodPut:
+		body := make([]byte, r.ContentLength)
+		_, _ = r.Body.Read(body)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		pair, exist := f.values[key]
+		if query.Has("cas") {
+			cas, _ := strconv.ParseUint(query.Get("cas"), 10, 64)
+			if (cas == 0 && exist) || (cas != 0 && (!exist || pair.ModifyIndex != cas)) {
+				_, _ = w.Write([]byte("false"))
+				return
+			}
+		}
+		f.index++
+		if !exist {
+			pair = &fakeConsulPair{Key: key, CreateIndex: f.index}
+			f.values[key] = pair
+		}
+		pair.Value, pair.ModifyIndex = body, f.index
+		f.notify()
+		_, _ = w.Write([]byte("true"))
+	case http.MethodDelete:
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if _, ok := f.values[key]; ok {
+			delete(f.values, key)
+			f.index++
+			f.notify()
+		}
+		_, _ = w.Write([]byte("true"))
+	}
+}
+
+// notify must be called with lock held
+func (f *fakeConsulKv) notify() {
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func TestConsulConfigClient(t *testing.T) {
+	server := newFakeConsulKv()
+	defer server.Close()
+	ctx := context.Background()
+	client := &ConsulConfigClient{}
+	err := client.Init(ctx, &Config{Type: "consul", Endpoints: server.URL, Namespace: "dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if err = client.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := client.CompareAndSet(ctx, "a.yaml", "1", "")
+	if err != nil || !ok {
+		t.Fatalf("cas on absent key %v %v", ok, err)
+	}
+	item, err := client.Get(ctx, "a.yaml")
+	if err != nil || item.GetValue() != "1" || item.GetRevision() == "" {
+		t.Fatalf("get %+v %v", item, err)
+	}
+	if ok, _ = client.CompareAndSet(ctx, "a.yaml", "2", "1"); ok {
+		t.Fatal("cas with stale revision succeeded")
+	}
+
+	watched := make(chan *Item, 10)
+	if err = client.Watch(ctx, item, func(item *Item) { watched <- item }); err != nil {
+		t.Fatal(err)
+	}
+	events := make(chan *Event, 10)
+	if err = client.WatchPrefix(ctx, "", func(event *Event) { events <- event }); err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, _ = client.CompareAndSet(ctx, "a.yaml", "2", item.GetRevision()); !ok {
+		t.Fatal("cas with current revision failed")
+	}
+	_ = client.Set(ctx, "b.yaml", "3")
+	items, err := client.List(ctx, "")
+	if err != nil || len(items) != 2 || items[0].Key != "a.yaml" || items[1].GetValue() != "3" {
+		t.Fatalf("list %v %v", items, err)
+	}
+	expectItem := func(value string, deleted bool) {
+		t.Helper()
+		select {
+		case item := <-watched:
+			if item.GetValue() != value || item.IsDeleted() != deleted {
+				t.Fatalf("watched %s deleted %t", item.GetValue(), item.IsDeleted())
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("watch timeout")
+		}
+	}
+	//blocking queries coalesce changes,so delete after the put observed
+	expectItem("2", false)
+	_ = client.Delete(ctx, "a.yaml")
+	expectItem("", true)
+
+	seen := map[string]EventType{}
+	for seen["a.yaml"] != EventDelete || seen["b.yaml"] == "" {
+		select {
+		case event := <-events:
+			seen[event.Item.Key] = event.Type
+		case <-time.After(5 * time.Second):
+			t.Fatalf("watch prefix timeout %v", seen)
+		}
+	}
+	if seen["b.yaml"] != EventPut {
+		t.Fatalf("unexpected events %v", seen)
+	}
+}
@@ -3,6 +3,13 @@ package config
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/no-mole/neptune/logger"
+	"go.etcd.io/etcd/api/v3/mvccpb"
 	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
@@ -18,14 +25,19 @@ var (
 	_ Client = &EtcdConfigClient{} //ensure EtcdConfigClient Implementation Client
 )
 
+// WatchRetryInterval interval before re-creating a broken etcd watch
+var WatchRetryInterval = time.Second
+
 type EtcdConfigClient struct {
-	config  *Config
-	client  *clientv3.Client
-	closeCh chan struct{}
+	config    *Config
+	client    *clientv3.Client
+	closeCh   chan struct{}
+	closeOnce sync.Once
 }
 
 func (s *EtcdConfigClient) Init(ctx context.Context, conf *Config) error {
 	s.config = conf
+	s.closeCh = make(chan struct{})
 
 	clientConf := Trans2EtcdConfig(ctx, conf)
 
@@ -37,12 +49,21 @@ func (s *EtcdConfigClient) Init(ctx context.Context, conf *Config) error {
 	return nil
 }
 
+// Close stop watches then close the etcd client
 func (s *EtcdConfigClient) Close() error {
-	close(s.closeCh)
-	return s.client.Close()
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.closeCh)
+		err = s.client.Close()
+	})
+	return err
 }
 
+// Set with the history setting enabled,author from WithAuthor and time are recorded
 func (s *EtcdConfigClient) Set(ctx context.Context, key, value string) error {
+	if s.historyEnabled() {
+		return s.versionedSet(ctx, key, value, "")
+	}
 	_, err := s.client.Put(ctx, s.genKey(key), value)
 	return err
 }
@@ -58,7 +79,177 @@ func (s *EtcdConfigClient) Get(ctx context.Context, key string) (*Item, error) {
 	} else {
 		value = string(resp.Kvs[0].Value)
 	}
-	return NewItem(s.config.Namespace, key, value), nil
+	item := NewItem(s.config.Namespace, key, value)
+	if len(resp.Kvs) != 0 {
+		item.SetRevision(strconv.FormatInt(resp.Kvs[0].ModRevision, 10))
+	}
+	return item, nil
+}
+
+func (s *EtcdConfigClient) List(ctx context.Context, prefix string) ([]*Item, error) {
+	resp, err := s.client.Get(ctx, s.genKey(prefix), clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*Item, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		items = append(items, s.item(kv))
+	}
+	return items, nil
+}
+
+// Delete with the history setting enabled,the delete is recorded in the audit key
+func (s *EtcdConfigClient) Delete(ctx context.Context, key string) error {
+	if s.historyEnabled() {
+		return s.versionedDelete(ctx, key)
+	}
+	_, err := s.client.Delete(ctx, s.genKey(key))
+	return err
+}
+
+// CompareAndSet put value in a txn comparing the mod revision of key
+func (s *EtcdConfigClient) CompareAndSet(ctx context.Context, key, value, revision string) (bool, error) {
+	cmp := clientv3.Compare(clientv3.CreateRevision(s.genKey(key)), "=", 0)
+	if revision != "" {
+		rev, err := strconv.ParseInt(revision, 10, 64)
+		if err != nil {
+			return false, fmt.Errorf("invalid etcd revision %s: %w", revision, err)
+		}
+		cmp = clientv3.Compare(clientv3.ModRevision(s.genKey(key)), "=", rev)
+	}
+	resp, err := s.client.Txn(ctx).If(cmp).Then(clientv3.OpPut(s.genKey(key), value)).Commit()
+	if err != nil {
+		return false, err
+	}
+	return resp.Succeeded, nil
+}
+
+// WatchPrefix resume from the revision of the initial listing,
+// puts and deletes missed during compaction are re-synced by listing prefix again
+func (s *EtcdConfigClient) WatchPrefix(ctx context.Context, prefix string, callback func(event *Event)) error {
+	if callback == nil {
+		return nil
+	}
+	known := map[string]int64{}
+	resync := func(ctx context.Context) (int64, error) {
+		resp, err := s.client.Get(ctx, s.genKey(prefix), clientv3.WithPrefix())
+		if err != nil {
+			return 0, err
+		}
+		current := make(map[string]int64, len(resp.Kvs))
+		for _, kv := range resp.Kvs {
+			current[string(kv.Key)] = kv.ModRevision
+			if rev, ok := known[string(kv.Key)]; !ok || rev != kv.ModRevision {
+				callback(&Event{Type: EventPut, Item: s.item(kv)})
+			}
+		}
+		for key := range known {
+			if _, ok := current[key]; !ok {
+				callback(&Event{Type: EventDelete, Item: s.deletedItem(key)})
+			}
+		}
+		known = current
+		return resp.Header.Revision, nil
+	}
+	rev, err := s.initialRevision(ctx, prefix, func(resp *clientv3.GetResponse) {
+		for _, kv := range resp.Kvs {
+			known[string(kv.Key)] = kv.ModRevision
+		}
+	}, clientv3.WithPrefix())
+	if err != nil {
+		return err
+	}
+	go s.watch(ctx, s.genKey(prefix), rev, resync, func(events []*clientv3.Event) {
+		for _, event := range events {
+			if event.Type == mvccpb.DELETE {
+				delete(known, string(event.Kv.Key))
+				callback(&Event{Type: EventDelete, Item: s.deletedItem(string(event.Kv.Key))})
+				continue
+			}
+			known[string(event.Kv.Key)] = event.Kv.ModRevision
+			callback(&Event{Type: EventPut, Item: s.item(event.Kv)})
+		}
+	}, clientv3.WithPrefix())
+	return nil
+}
+
+// initialRevision revision to start watching from,fn receive the current kvs
+func (s *EtcdConfigClient) initialRevision(ctx context.Context, key string, fn func(resp *clientv3.GetResponse), opts ...clientv3.OpOption) (int64, error) {
+	resp, err := s.client.Get(ctx, s.genKey(key), opts...)
+	if err != nil {
+		return 0, err
+	}
+	fn(resp)
+	return resp.Header.Revision, nil
+}
+
+// watch watch key after rev until ctx is done or the client closed.
+// The watch is re-created from the last seen revision when the channel closed or failed,
+// and resync is called to fetch the current state when the revision was compacted
+func (s *EtcdConfigClient) watch(ctx context.Context, key string, rev int64,
+	resync func(ctx context.Context) (int64, error), handle func(events []*clientv3.Event), opts ...clientv3.OpOption) {
+	for {
+		rev = s.watchOnce(ctx, key, rev, resync, handle, opts...)
+		select {
+		case <-time.After(WatchRetryInterval):
+		case <-s.closeCh:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// watchOnce returns the revision to resume from when the watch broken
+func (s *EtcdConfigClient) watchOnce(ctx context.Context, key string, rev int64,
+	resync func(ctx context.Context) (int64, error), handle func(events []*clientv3.Event), opts ...clientv3.OpOption) int64 {
+	watchCtx, cancel := context.WithCancel(clientv3.WithRequireLeader(ctx))
+	defer cancel()
+	watchCh := s.client.Watch(watchCtx, key, append(opts, clientv3.WithRev(rev+1))...)
+	for {
+		select {
+		case wResp, ok := <-watchCh:
+			if !ok {
+				return rev
+			}
+			if wResp.CompactRevision != 0 {
+				logger.Warning(ctx, "etcd config watch compacted,resync", wResp.Err(), logger.WithField("key", key),
+					logger.WithField("revision", rev), logger.WithField("compactRevision", wResp.CompactRevision))
+				newRev, err := resync(ctx)
+				if err != nil {
+					logger.Error(ctx, "etcd config watch resync", err, logger.WithField("key", key))
+					return rev
+				}
+				return newRev
+			}
+			if err := wResp.Err(); err != nil {
+				logger.Error(ctx, "etcd config watch", err, logger.WithField("key", key))
+				return rev
+			}
+			if len(wResp.Events) > 0 {
+				handle(wResp.Events)
+				rev = wResp.Events[len(wResp.Events)-1].Kv.ModRevision
+			}
+		case <-s.closeCh:
+			return rev
+		case <-ctx.Done():
+			return rev
+		}
+	}
+}
+
+// deletedItem item of deleted etcd key
+func (s *EtcdConfigClient) deletedItem(key string) *Item {
+	item := NewItem(s.config.Namespace, strings.TrimPrefix(key, s.genKey("")), "")
+	item.SetDeleted()
+	return item
+}
+
+// item convert kv to item with key relative to namespace
+func (s *EtcdConfigClient) item(kv *mvccpb.KeyValue) *Item {
+	item := NewItem(s.config.Namespace, strings.TrimPrefix(string(kv.Key), s.genKey("")), string(kv.Value))
+	item.SetRevision(strconv.FormatInt(kv.ModRevision, 10))
+	return item
 }
 
 func (s *EtcdConfigClient) Exist(ctx context.Context, key string) (bool, error) {
@@ -69,27 +260,53 @@ func (s *EtcdConfigClient) Exist(ctx context.Context, key string) (bool, error)
 	return len(resp.Kvs) != 0, nil
 }
 
+// Ping check connectivity by counting a key in namespace
+func (s *EtcdConfigClient) Ping(ctx context.Context) error {
+	_, err := s.client.Get(ctx, s.genKey(""), clientv3.WithCountOnly())
+	return err
+}
+
+// Watch resume from the last seen revision after etcd restarts,
+// deletes are delivered with item.IsDeleted() true
 func (s *EtcdConfigClient) Watch(ctx context.Context, item *Item, callback func(item *Item)) error {
 	if callback == nil {
 		return nil
 	}
-	watchCh := s.client.Watch(ctx, s.genKey(item.Key))
-	go func() {
-		for {
-			select {
-			case wResp := <-watchCh:
-				if len(wResp.Events) > 0 {
-					event := wResp.Events[len(wResp.Events)-1]
-					item.SetValue(string(event.Kv.Value))
-					callback(item)
-				}
-			case <-s.closeCh:
-				return
-			case <-ctx.Done():
-				return
+	apply := func(kv *mvccpb.KeyValue, deleted bool) {
+		if deleted {
+			item.SetDeleted()
+		} else {
+			item.SetValue(string(kv.Value))
+			item.SetRevision(strconv.FormatInt(kv.ModRevision, 10))
+		}
+		callback(item)
+	}
+	resync := func(ctx context.Context) (int64, error) {
+		resp, err := s.client.Get(ctx, s.genKey(item.Key))
+		if err != nil {
+			return 0, err
+		}
+		if len(resp.Kvs) == 0 {
+			if !item.IsDeleted() {
+				apply(nil, true)
 			}
+		} else if strconv.FormatInt(resp.Kvs[0].ModRevision, 10) != item.GetRevision() {
+			apply(resp.Kvs[0], false)
 		}
-	}()
+		return resp.Header.Revision, nil
+	}
+	rev, err := s.initialRevision(ctx, item.Key, func(*clientv3.GetResponse) {})
+	if err != nil {
+		return err
+	}
+	//resume from the revision of item to not miss changes between Get and Watch
+	if itemRev, err := strconv.ParseInt(item.GetRevision(), 10, 64); err == nil && itemRev < rev {
+		rev = itemRev
+	}
+	go s.watch(ctx, s.genKey(item.Key), rev, resync, func(events []*clientv3.Event) {
+		event := events[len(events)-1]
+		apply(event.Kv, event.Type == mvccpb.DELETE)
+	})
 	return nil
 }
 
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/no-mole/neptune/json"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+var (
+	_ Historian = &EtcdConfigClient{} //ensure EtcdConfigClient Implementation Historian
+)
+
+// etcdAudit audit record written with the value in one txn,so that it shares the mod revision of the value
+type etcdAudit struct {
+	Author  string    `json:"author,omitempty"`
+	Time    time.Time `json:"time"`
+	Comment string    `json:"comment,omitempty"`
+}
+
+// historyEnabled versioned writes are enabled by the history setting
+func (s *EtcdConfigClient) historyEnabled() bool {
+	enabled, _ := strconv.ParseBool(s.config.Settings["history"])
+	return enabled
+}
+
+// auditKey outside of namespace to keep it out of List
+func (s *EtcdConfigClient) auditKey(key string) string {
+	return "/_audit" + s.genKey(key)
+}
+
+// versionedSet put value and audit record of author from ctx in one txn
+func (s *EtcdConfigClient) versionedSet(ctx context.Context, key, value, comment string) error {
+	audit, err := s.auditOp(ctx, key, comment)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.Txn(ctx).Then(clientv3.OpPut(s.genKey(key), value), audit).Commit()
+	return err
+}
+
+// versionedDelete delete key and put audit record of the delete in one txn
+func (s *EtcdConfigClient) versionedDelete(ctx context.Context, key string) error {
+	audit, err := s.auditOp(ctx, key, "delete")
+	if err != nil {
+		return err
+	}
+	_, err = s.client.Txn(ctx).Then(clientv3.OpDelete(s.genKey(key)), audit).Commit()
+	return err
+}
+
+func (s *EtcdConfigClient) auditOp(ctx context.Context, key, comment string) (clientv3.Op, error) {
+	audit, err := json.Marshal(&etcdAudit{Author: AuthorFromContext(ctx), Time: time.Now(), Comment: comment})
+	if err != nil {
+		return clientv3.Op{}, err
+	}
+	return clientv3.OpPut(s.auditKey(key), string(audit)), nil
+}
+
+// History walk mod revisions of key back until its first creation or the compacted revision,
+// author and time are available for revisions written with the history setting enabled.
+// Deletes written with the history setting enabled are returned as revisions with empty value,
+// and the walk continues before them through the audit key
+func (s *EtcdConfigClient) History(ctx context.Context, key string, limit int) ([]*Revision, error) {
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+	var revisions []*Revision
+	var rev int64 //0 means latest
+	for len(revisions) <= limit {
+		resp, err := s.client.Get(ctx, s.genKey(key), clientv3.WithRev(rev))
+		if errors.Is(err, rpctypes.ErrCompacted) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Kvs) == 0 {
+			//key not exist at rev,find the delete recorded before it
+			deleted, next, err := s.deleteRevision(ctx, key, rev)
+			if err != nil {
+				return nil, err
+			}
+			if deleted != nil {
+				revisions = append(revisions, deleted)
+			}
+			if next == 0 {
+				break
+			}
+			rev = next
+			continue
+		}
+		kv := resp.Kvs[0]
+		revision := &Revision{Version: strconv.FormatInt(kv.ModRevision, 10), Value: string(kv.Value)}
+		if audit, ok := s.audit(ctx, key, kv.ModRevision); ok {
+			revision.Author, revision.Time, revision.Comment = audit.Author, audit.Time, audit.Comment
+		}
+		revisions = append(revisions, revision)
+		if kv.ModRevision <= 1 {
+			break
+		}
+		//versions before the key was created again are found through the audit key
+		rev = kv.ModRevision - 1
+	}
+	//one more revision fetched to fill Previous of the last one
+	for i := 0; i+1 < len(revisions); i++ {
+		revisions[i].Previous = revisions[i+1].Value
+	}
+	if len(revisions) > limit {
+		revisions = revisions[:limit]
+	}
+	return revisions, nil
+}
+
+// audit record written at mod revision rev of key
+func (s *EtcdConfigClient) audit(ctx context.Context, key string, rev int64) (*etcdAudit, bool) {
+	resp, err := s.client.Get(ctx, s.auditKey(key), clientv3.WithRev(rev))
+	if err != nil || len(resp.Kvs) == 0 || resp.Kvs[0].ModRevision != rev {
+		return nil, false
+	}
+	audit := &etcdAudit{}
+	if json.Unmarshal(resp.Kvs[0].Value, audit) != nil {
+		return nil, false
+	}
+	return audit, true
+}
+
+// deleteRevision key not exist at rev,the latest audit record at or before rev tells where to continue:
+// a record written when the key was not exist is the delete,and the walk continues before it,
+// otherwise the key was deleted without history and the walk continues at the record.
+// next is 0 when there is no earlier record
+func (s *EtcdConfigClient) deleteRevision(ctx context.Context, key string, rev int64) (deleted *Revision, next int64, err error) {
+	resp, err := s.client.Get(ctx, s.auditKey(key), clientv3.WithRev(rev))
+	if errors.Is(err, rpctypes.ErrCompacted) {
+		return nil, 0, nil
+	}
+	if err != nil || len(resp.Kvs) == 0 {
+		return nil, 0, err
+	}
+	auditRev := resp.Kvs[0].ModRevision
+	keyResp, err := s.client.Get(ctx, s.genKey(key), clientv3.WithRev(auditRev), clientv3.WithCountOnly())
+	if errors.Is(err, rpctypes.ErrCompacted) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	if keyResp.Count > 0 {
+		return nil, auditRev, nil
+	}
+	deleted = &Revision{Version: strconv.FormatInt(auditRev, 10)}
+	audit := &etcdAudit{}
+	if json.Unmarshal(resp.Kvs[0].Value, audit) == nil {
+		deleted.Author, deleted.Time, deleted.Comment = audit.Author, audit.Time, audit.Comment
+	}
+	return deleted, auditRev - 1, nil
+}
+
+// Rollback set key to the value at mod revision version
+func (s *EtcdConfigClient) Rollback(ctx context.Context, key, version string) error {
+	rev, err := strconv.ParseInt(version, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid etcd revision %s: %w", version, err)
+	}
+	resp, err := s.client.Get(ctx, s.genKey(key), clientv3.WithRev(rev))
+	if err != nil {
+		return err
+	}
+	if len(resp.Kvs) == 0 || resp.Kvs[0].ModRevision != rev {
+		return fmt.Errorf("revision %s of config key [%s] not found", version, key)
+	}
+	return s.versionedSet(ctx, key, string(resp.Kvs[0].Value), "rollback to revision "+version)
+}
@@ -0,0 +1,402 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/no-mole/neptune/logger"
+	"gopkg.in/yaml.v3"
+)
+
+var RegistryImplementationTypeNameFile = "file"
+
+// FileWatchDebounce quiet period after the last fs event before the file is read,
+// writers not replacing the file atomically truncate it first
+var FileWatchDebounce = 50 * time.Millisecond
+
+// ErrKeyOutsideDir key of directory mode resolves to a file outside {dir}/{namespace},such as ../secret
+var ErrKeyOutsideDir = errors.New("config key resolves outside of the config directory")
+
+func init() {
+	RegistryImplementation(RegistryImplementationTypeNameFile, func(ctx context.Context) Client {
+		return &FileConfigClient{}
+	})
+}
+
+var (
+	_ Client = &FileConfigClient{} //ensure FileConfigClient Implementation Client
+)
+
+// FileConfigClient config client for local development,Endpoints is a directory or a yaml file.
+// In directory mode the value of key is the content of file {dir}/{namespace}/{key},
+// in yaml file mode values are top level keys of the document,or keys of the namespace map when namespace is set
+type FileConfigClient struct {
+	config *Config
+	path   string
+	isDir  bool
+
+	mu        sync.Mutex //serialize writes of yaml file
+	closeCh   chan struct{}
+	closeOnce sync.Once
+}
+
+func (s *FileConfigClient) Init(_ context.Context, conf *Config) error {
+	s.config = conf
+	s.path = conf.Endpoints
+	s.closeCh = make(chan struct{})
+	if s.path == "" {
+		return errors.New("file config client endpoints is empty")
+	}
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+	s.isDir = info.IsDir()
+	return nil
+}
+
+func (s *FileConfigClient) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.closeCh)
+	})
+	return nil
+}
+
+func (s *FileConfigClient) Set(_ context.Context, key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.set(key, value)
+}
+
+// set write value of key,caller must hold mu
+func (s *FileConfigClient) set(key, value string) error {
+	if s.isDir {
+		filename, err := s.filename(key)
+		if err != nil {
+			return err
+		}
+		err = os.MkdirAll(filepath.Dir(filename), 0755)
+		if err != nil {
+			return err
+		}
+		return writeFile(filename, []byte(value))
+	}
+	return s.updateDocument(func(values map[string]any) {
+		values[key] = value
+	})
+}
+
+// updateDocument update values of namespace in yaml file
+func (s *FileConfigClient) updateDocument(fn func(values map[string]any)) error {
+	doc, err := s.readDocument()
+	if err != nil {
+		return err
+	}
+	values := doc
+	if s.config.Namespace != "" {
+		ns, ok := doc[s.config.Namespace].(map[string]any)
+		if !ok {
+			ns = map[string]any{}
+			doc[s.config.Namespace] = ns
+		}
+		values = ns
+	}
+	fn(values)
+	body, err := yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return writeFile(s.path, body)
+}
+
+// keys all keys in namespace,temp files of writeFile are skipped
+func (s *FileConfigClient) keys() ([]string, error) {
+	var keys []string
+	if !s.isDir {
+		doc, err := s.readDocument()
+		if err != nil {
+			return nil, err
+		}
+		values := doc
+		if s.config.Namespace != "" {
+			values, _ = doc[s.config.Namespace].(map[string]any)
+		}
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return keys, nil
+	}
+	root := filepath.Join(s.path, s.config.Namespace)
+	err := filepath.WalkDir(root, func(filename string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		rel, err := filepath.Rel(root, filename)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, filepath.ToSlash(rel))
+		return nil
+	})
+	return keys, err
+}
+
+func (s *FileConfigClient) Get(_ context.Context, key string) (*Item, error) {
+	value, exist, err := s.read(key)
+	if err != nil {
+		return nil, err
+	}
+	item := NewItem(s.config.Namespace, key, value)
+	if exist {
+		item.SetRevision(md5Revision(value))
+	}
+	return item, nil
+}
+
+func (s *FileConfigClient) List(_ context.Context, prefix string) ([]*Item, error) {
+	keys, err := s.keys()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*Item, 0, len(keys))
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		value, exist, err := s.read(key)
+		if err != nil {
+			return nil, err
+		}
+		if !exist {
+			continue
+		}
+		item := NewItem(s.config.Namespace, key, value)
+		item.SetRevision(md5Revision(value))
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (s *FileConfigClient) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isDir {
+		filename, err := s.filename(key)
+		if err != nil {
+			return err
+		}
+		err = os.Remove(filename)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	return s.updateDocument(func(values map[string]any) {
+		delete(values, key)
+	})
+}
+
+// CompareAndSet revision is md5 of the current value,
+// compare and write are done under mu so concurrent callers of this client can not both succeed
+func (s *FileConfigClient) CompareAndSet(_ context.Context, key, value, revision string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, exist, err := s.read(key)
+	if err != nil {
+		return false, err
+	}
+	currentRevision := ""
+	if exist {
+		currentRevision = md5Revision(current)
+	}
+	if currentRevision != revision {
+		return false, nil
+	}
+	return true, s.set(key, value)
+}
+
+// WatchPrefix changes are polled every watch_interval
+func (s *FileConfigClient) WatchPrefix(ctx context.Context, prefix string, callback func(event *Event)) error {
+	if callback == nil {
+		return nil
+	}
+	return pollPrefix(ctx, s.closeCh, watchInterval(s.config), prefix, s.List, callback)
+}
+
+func (s *FileConfigClient) Exist(_ context.Context, key string) (bool, error) {
+	_, exist, err := s.read(key)
+	return exist, err
+}
+
+// Ping check the directory or file still exists
+func (s *FileConfigClient) Ping(_ context.Context) error {
+	_, err := os.Stat(s.path)
+	return err
+}
+
+// Watch watch the directory of the file by fsnotify,callback is called when the value changed
+func (s *FileConfigClient) Watch(ctx context.Context, item *Item, callback func(item *Item)) error {
+	if callback == nil {
+		return nil
+	}
+	filename := s.path
+	if s.isDir {
+		var err error
+		filename, err = s.filename(item.Key)
+		if err != nil {
+			return err
+		}
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	//watch the directory to survive editors replacing the file
+	dir := filepath.Dir(filename)
+	err = os.MkdirAll(dir, 0755)
+	if err == nil {
+		err = watcher.Add(dir)
+	}
+	if err != nil {
+		_ = watcher.Close()
+		return err
+	}
+	go func() {
+		defer watcher.Close()
+		last := item.GetValue()
+		debounce := time.NewTimer(FileWatchDebounce)
+		debounce.Stop()
+		defer debounce.Stop()
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) == filepath.Clean(filename) {
+					debounce.Reset(FileWatchDebounce)
+				}
+			case <-debounce.C:
+				value, exist, err := s.read(item.Key)
+				if err != nil {
+					logger.Error(ctx, "file config watch", err, logger.WithField("key", item.Key))
+					continue
+				}
+				if value == last && exist != item.IsDeleted() {
+					continue
+				}
+				last = value
+				if exist {
+					item.SetValue(value)
+					item.SetRevision(md5Revision(value))
+				} else {
+					item.SetDeleted()
+				}
+				callback(item)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.Error(ctx, "file config watch", err, logger.WithField("key", item.Key))
+			case <-s.closeCh:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// read value of key,exist is false when the file or the key not exist
+func (s *FileConfigClient) read(key string) (value string, exist bool, err error) {
+	if s.isDir {
+		filename, err := s.filename(key)
+		if err != nil {
+			return "", false, err
+		}
+		body, err := os.ReadFile(filename)
+		if errors.Is(err, os.ErrNotExist) {
+			return "", false, nil
+		}
+		return string(body), err == nil, err
+	}
+	doc, err := s.readDocument()
+	if err != nil {
+		return "", false, err
+	}
+	values := doc
+	if s.config.Namespace != "" {
+		values, _ = doc[s.config.Namespace].(map[string]any)
+	}
+	val, ok := values[key]
+	if !ok || val == nil {
+		return "", ok, nil
+	}
+	if str, ok := val.(string); ok {
+		return str, true, nil
+	}
+	//structured values are returned as yaml
+	body, err := yaml.Marshal(val)
+	if err != nil {
+		return "", true, err
+	}
+	return strings.TrimSuffix(string(body), "\n"), true, nil
+}
+
+func (s *FileConfigClient) readDocument() (map[string]any, error) {
+	body, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	doc := map[string]any{}
+	err = yaml.Unmarshal(body, &doc)
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// filename file of key in directory mode,keys escaping {dir}/{namespace} are rejected
+func (s *FileConfigClient) filename(key string) (string, error) {
+	root := filepath.Join(s.path, s.config.Namespace)
+	filename := filepath.Join(root, filepath.FromSlash(key))
+	rel, err := filepath.Rel(root, filename)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s", ErrKeyOutsideDir, key)
+	}
+	return filename, nil
+}
+
+// writeFile write to a temp file then rename,readers never see a partially written file
+func writeFile(filename string, body []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFileConfigClientDir(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir := t.TempDir()
+	cli := &FileConfigClient{}
+	err := cli.Init(ctx, &Config{Type: "file", Endpoints: dir, Namespace: "dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+	err = cli.Set(ctx, "mysql/main.yaml", "host: localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := os.ReadFile(filepath.Join(dir, "dev", "mysql", "main.yaml"))
+	if string(body) != "host: localhost" {
+		t.Fatalf("unexpected file content %q", body)
+	}
+	item, err := cli.Get(ctx, "mysql/main.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := make(chan string, 1)
+	err = cli.Watch(ctx, item, func(item *Item) {
+		changed <- item.GetValue()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "dev", "mysql", "main.yaml"), []byte("host: db"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case value := <-changed:
+		if value != "host: db" {
+			t.Errorf("unexpected watched value %q", value)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch timeout")
+	}
+}
+
+func TestFileConfigClientKeyOutsideDir(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "secret")
+	if err := os.WriteFile(secret, []byte("password"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cli := &FileConfigClient{}
+	if err := cli.Init(ctx, &Config{Type: "file", Endpoints: dir, Namespace: "dev"}); err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+	for _, key := range []string{"../secret", "mysql/../../secret", "../../tmp/x", ""} {
+		if _, err := cli.Get(ctx, key); !errors.Is(err, ErrKeyOutsideDir) {
+			t.Errorf("get %q should be rejected,got %v", key, err)
+		}
+		if err := cli.Set(ctx, key, "x"); !errors.Is(err, ErrKeyOutsideDir) {
+			t.Errorf("set %q should be rejected,got %v", key, err)
+		}
+		if err := cli.Delete(ctx, key); !errors.Is(err, ErrKeyOutsideDir) {
+			t.Errorf("delete %q should be rejected,got %v", key, err)
+		}
+	}
+	if body, err := os.ReadFile(secret); err != nil || string(body) != "password" {
+		t.Fatalf("file outside config directory should be untouched,got %q %v", body, err)
+	}
+	//keys cleaned inside the directory are allowed
+	if err := cli.Set(ctx, "mysql/../redis.yaml", "addr: a"); err != nil {
+		t.Fatal(err)
+	}
+	if item, err := cli.Get(ctx, "redis.yaml"); err != nil || item.GetValue() != "addr: a" {
+		t.Fatalf("unexpected value %v %v", item, err)
+	}
+}
+
+func TestFileConfigClientYaml(t *testing.T) {
+	ctx := context.Background()
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(filename, []byte("dev:\n  flag: \"true\"\n  redis:\n    addr: localhost:6379\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := &FileConfigClient{}
+	err = cli.Init(ctx, &Config{Type: "file", Endpoints: filename, Namespace: "dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	item, _ := cli.Get(ctx, "redis")
+	if item.GetValue() != "addr: localhost:6379" {
+		t.Errorf("structured value should be yaml,got %q", item.GetValue())
+	}
+	_ = cli.Set(ctx, "flag", "false")
+	item, _ = cli.Get(ctx, "flag")
+	if item.GetValue() != "false" {
+		t.Errorf("unexpected value %q", item.GetValue())
+	}
+	exist, _ := cli.Exist(ctx, "missing")
+	if exist {
+		t.Error("missing key should not exist")
+	}
+}
+
+func TestFileConfigClientListAndCompareAndSet(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cli := &FileConfigClient{}
+	err := cli.Init(ctx, &Config{Type: "file", Endpoints: t.TempDir(), Namespace: "dev",
+		Settings: map[string]string{"watch_interval": "10ms"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+	ok, err := cli.CompareAndSet(ctx, "redis/main", "a", "")
+	if err != nil || !ok {
+		t.Fatalf("create by compare and set failed:%v %v", ok, err)
+	}
+	ok, _ = cli.CompareAndSet(ctx, "redis/main", "b", "")
+	if ok {
+		t.Fatal("compare and set with stale revision succeeded")
+	}
+	events := make(chan *Event, 4)
+	err = cli.WatchPrefix(ctx, "redis/", func(event *Event) {
+		events <- event
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	item, _ := cli.Get(ctx, "redis/main")
+	ok, err = cli.CompareAndSet(ctx, "redis/main", "b", item.GetRevision())
+	if err != nil || !ok {
+		t.Fatalf("compare and set failed:%v %v", ok, err)
+	}
+	_ = cli.Set(ctx, "mysql/main", "c")
+	items, err := cli.List(ctx, "redis/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Key != "redis/main" || items[0].GetValue() != "b" {
+		t.Fatalf("unexpected list result %+v", items)
+	}
+	for _, want := range []EventType{EventPut, EventDelete} {
+		select {
+		case event := <-events:
+			if event.Type != want || event.Item.Key != "redis/main" {
+				t.Fatalf("unexpected event %s %s", event.Type, event.Item.Key)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("watch prefix timeout")
+		}
+		if want == EventPut {
+			_ = cli.Delete(ctx, "redis/main")
+		}
+	}
+}
+
+func TestFileConfigClientConcurrentCompareAndSet(t *testing.T) {
+	ctx := context.Background()
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(filename, []byte("dev: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for name, endpoints := range map[string]string{"dir": t.TempDir(), "yaml": filename} {
+		t.Run(name, func(t *testing.T) {
+			cli := &FileConfigClient{}
+			err := cli.Init(ctx, &Config{Type: "file", Endpoints: endpoints, Namespace: "dev"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer cli.Close()
+			_ = cli.Set(ctx, "counter", "0")
+			item, _ := cli.Get(ctx, "counter")
+
+			var wg sync.WaitGroup
+			var won atomic.Int32
+			start := make(chan struct{})
+			for i := 0; i < 50; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					<-start
+					ok, err := cli.CompareAndSet(ctx, "counter", strconv.Itoa(i+1), item.GetRevision())
+					if err != nil {
+						t.Error(err)
+					}
+					if ok {
+						won.Add(1)
+					}
+				}(i)
+			}
+			close(start)
+			wg.Wait()
+			if won.Load() != 1 {
+				t.Fatalf("exactly one compare and set should win,got %d", won.Load())
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrHistoryNotSupported = errors.New("config client not support history")
+
+// DefaultHistoryLimit number of revisions returned by History when limit <= 0
+var DefaultHistoryLimit = 10
+
+// Revision a historical version of key,newest first in History
+type Revision struct {
+	Version  string    `json:"version"`
+	Value    string    `json:"value"`
+	Previous string    `json:"previous,omitempty"`
+	Author   string    `json:"author,omitempty"`
+	Time     time.Time `json:"time,omitempty"`
+	Comment  string    `json:"comment,omitempty"`
+}
+
+// Historian is implemented by clients keeping change history of keys
+type Historian interface {
+	// History revisions of key newest first,at most limit
+	History(ctx context.Context, key string, limit int) ([]*Revision, error)
+	// Rollback set key to the value of version,recorded as a new revision
+	Rollback(ctx context.Context, key, version string) error
+}
+
+// Unwrapper is implemented by clients wrapping another client
+type Unwrapper interface {
+	Unwrap() Client
+}
+
+type authorKey struct{}
+
+// WithAuthor author recorded by versioned writes
+func WithAuthor(ctx context.Context, author string) context.Context {
+	return context.WithValue(ctx, authorKey{}, author)
+}
+
+func AuthorFromContext(ctx context.Context) string {
+	author, _ := ctx.Value(authorKey{}).(string)
+	return author
+}
+
+// AsHistorian find Historian in client and the clients it wraps
+func AsHistorian(client Client) (Historian, bool) {
+	for client != nil {
+		if h, ok := client.(Historian); ok {
+			return h, true
+		}
+		u, ok := client.(Unwrapper)
+		if !ok {
+			break
+		}
+		client = u.Unwrap()
+	}
+	return nil, false
+}
+
+func History(ctx context.Context, key string, limit int) ([]*Revision, error) {
+	h, ok := AsHistorian(defaultClient)
+	if !ok {
+		return nil, ErrHistoryNotSupported
+	}
+	return h.History(ctx, key, limit)
+}
+
+func Rollback(ctx context.Context, key, version string) error {
+	h, ok := AsHistorian(defaultClient)
+	if !ok {
+		return ErrHistoryNotSupported
+	}
+	return h.Rollback(ctx, key, version)
+}
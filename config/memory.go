@@ -0,0 +1,255 @@
+package config
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var RegistryImplementationTypeNameMemory = "memory"
+
+func init() {
+	RegistryImplementation(RegistryImplementationTypeNameMemory, func(ctx context.Context) Client {
+		return NewMemoryConfigClient(nil)
+	})
+}
+
+var (
+	_ Client = &MemoryConfigClient{} //ensure MemoryConfigClient Implementation Client
+)
+
+// NewMemoryConfigClient in-memory config client for tests,values are updated by Set
+func NewMemoryConfigClient(values map[string]string) *MemoryConfigClient {
+	m := &MemoryConfigClient{
+		values:         map[string]string{},
+		revisions:      map[string]int64{},
+		watchers:       map[string]map[int]*memoryWatcher{},
+		prefixWatchers: map[int]*memoryPrefixWatcher{},
+	}
+	for k, v := range values {
+		m.revision++
+		m.values[k] = v
+		m.revisions[k] = m.revision
+	}
+	return m
+}
+
+type MemoryConfigClient struct {
+	config *Config
+
+	mu             sync.RWMutex
+	values         map[string]string
+	revisions      map[string]int64 //revision of each key,increased by every change
+	revision       int64
+	watchers       map[string]map[int]*memoryWatcher
+	prefixWatchers map[int]*memoryPrefixWatcher
+	nextId         int
+}
+
+type memoryPrefixWatcher struct {
+	prefix   string
+	callback func(event *Event)
+	mu       sync.Mutex
+}
+
+type memoryWatcher struct {
+	item     *Item
+	callback func(item *Item)
+	//serialize callbacks of one watcher
+	mu sync.Mutex
+}
+
+func (m *MemoryConfigClient) Init(_ context.Context, conf *Config) error {
+	m.config = conf
+	return nil
+}
+
+func (m *MemoryConfigClient) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.watchers = map[string]map[int]*memoryWatcher{}
+	m.prefixWatchers = map[int]*memoryPrefixWatcher{}
+	return nil
+}
+
+// Set update value and notify watchers of key
+func (m *MemoryConfigClient) Set(_ context.Context, key, value string) error {
+	m.mu.Lock()
+	m.put(key, value)
+	return nil
+}
+
+// put must be called with m.mu locked,unlock it before notifying watchers
+func (m *MemoryConfigClient) put(key, value string) {
+	m.revision++
+	m.values[key] = value
+	m.revisions[key] = m.revision
+	watchers := make([]*memoryWatcher, 0, len(m.watchers[key]))
+	for _, w := range m.watchers[key] {
+		watchers = append(watchers, w)
+	}
+	prefixWatchers := m.matchPrefixWatchers(key)
+	revision := m.itemRevision(key)
+	m.mu.Unlock()
+	for _, w := range watchers {
+		w.mu.Lock()
+		w.item.SetValue(value)
+		w.item.SetRevision(revision)
+		w.callback(w.item)
+		w.mu.Unlock()
+	}
+	for _, w := range prefixWatchers {
+		item := NewItem(m.namespace(), key, value)
+		item.SetRevision(revision)
+		w.notify(&Event{Type: EventPut, Item: item})
+	}
+}
+
+func (m *MemoryConfigClient) Get(_ context.Context, key string) (*Item, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	item := NewItem(m.namespace(), key, m.values[key])
+	item.SetRevision(m.itemRevision(key))
+	return item, nil
+}
+
+func (m *MemoryConfigClient) List(_ context.Context, prefix string) ([]*Item, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys := make([]string, 0, len(m.values))
+	for key := range m.values {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	items := make([]*Item, 0, len(keys))
+	for _, key := range keys {
+		item := NewItem(m.namespace(), key, m.values[key])
+		item.SetRevision(m.itemRevision(key))
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// Delete remove key and notify watchers
+func (m *MemoryConfigClient) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	if _, ok := m.values[key]; !ok {
+		m.mu.Unlock()
+		return nil
+	}
+	m.revision++
+	delete(m.values, key)
+	delete(m.revisions, key)
+	watchers := make([]*memoryWatcher, 0, len(m.watchers[key]))
+	for _, w := range m.watchers[key] {
+		watchers = append(watchers, w)
+	}
+	prefixWatchers := m.matchPrefixWatchers(key)
+	m.mu.Unlock()
+	for _, w := range watchers {
+		w.mu.Lock()
+		w.item.SetDeleted()
+		w.callback(w.item)
+		w.mu.Unlock()
+	}
+	for _, w := range prefixWatchers {
+		item := NewItem(m.namespace(), key, "")
+		item.SetDeleted()
+		w.notify(&Event{Type: EventDelete, Item: item})
+	}
+	return nil
+}
+
+func (m *MemoryConfigClient) CompareAndSet(_ context.Context, key, value, revision string) (bool, error) {
+	m.mu.Lock()
+	if m.itemRevision(key) != revision {
+		m.mu.Unlock()
+		return false, nil
+	}
+	m.put(key, value)
+	return true, nil
+}
+
+// WatchPrefix callback is called synchronously by Set and Delete until ctx is done
+func (m *MemoryConfigClient) WatchPrefix(ctx context.Context, prefix string, callback func(event *Event)) error {
+	if callback == nil {
+		return nil
+	}
+	m.mu.Lock()
+	id := m.nextId
+	m.nextId++
+	m.prefixWatchers[id] = &memoryPrefixWatcher{prefix: prefix, callback: callback}
+	m.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.prefixWatchers, id)
+	}()
+	return nil
+}
+
+func (m *MemoryConfigClient) Exist(_ context.Context, key string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.values[key]
+	return ok, nil
+}
+
+// Watch callback is called synchronously by Set until ctx is done
+func (m *MemoryConfigClient) Watch(ctx context.Context, item *Item, callback func(item *Item)) error {
+	if callback == nil {
+		return nil
+	}
+	m.mu.Lock()
+	id := m.nextId
+	m.nextId++
+	if m.watchers[item.Key] == nil {
+		m.watchers[item.Key] = map[int]*memoryWatcher{}
+	}
+	m.watchers[item.Key][id] = &memoryWatcher{item: item, callback: callback}
+	m.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.watchers[item.Key], id)
+	}()
+	return nil
+}
+
+func (m *MemoryConfigClient) matchPrefixWatchers(key string) []*memoryPrefixWatcher {
+	watchers := make([]*memoryPrefixWatcher, 0, len(m.prefixWatchers))
+	for _, w := range m.prefixWatchers {
+		if strings.HasPrefix(key, w.prefix) {
+			watchers = append(watchers, w)
+		}
+	}
+	return watchers
+}
+
+// itemRevision empty when key not exist
+func (m *MemoryConfigClient) itemRevision(key string) string {
+	revision, ok := m.revisions[key]
+	if !ok {
+		return ""
+	}
+	return strconv.FormatInt(revision, 10)
+}
+
+func (w *memoryPrefixWatcher) notify(event *Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callback(event)
+}
+
+func (m *MemoryConfigClient) namespace() string {
+	if m.config == nil {
+		return ""
+	}
+	return m.config.Namespace
+}
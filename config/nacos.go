@@ -3,8 +3,12 @@ package config
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
+
 	"github.com/nacos-group/nacos-sdk-go/v2/clients"
 	"github.com/nacos-group/nacos-sdk-go/v2/clients/config_client"
+	"github.com/nacos-group/nacos-sdk-go/v2/common/constant"
 	"github.com/nacos-group/nacos-sdk-go/v2/vo"
 )
 
@@ -17,15 +21,18 @@ func init() {
 }
 
 type NacosConfigClient struct {
-	group   string
-	conf    *Config
-	client  config_client.IConfigClient
-	closeCh chan struct{}
+	group     string
+	conf      *Config
+	client    config_client.IConfigClient
+	servers   []constant.ServerConfig //used by history open api
+	closeCh   chan struct{}
+	closeOnce sync.Once
 }
 
 func (s *NacosConfigClient) Init(ctx context.Context, conf *Config) error {
 	s.conf = conf
 	s.group = "DEFAULT_GROUP"
+	s.closeCh = make(chan struct{})
 
 	if group, ok := conf.Settings["group"]; ok {
 		s.group = group
@@ -48,17 +55,28 @@ func (s *NacosConfigClient) Init(ctx context.Context, conf *Config) error {
 	}
 
 	s.client = cli
+	s.servers = serverConfigs
 	return nil
 
 }
 
 func (s *NacosConfigClient) Close() error {
-	close(s.closeCh)
-	s.client.CloseClient()
+	s.closeOnce.Do(func() {
+		close(s.closeCh)
+		s.client.CloseClient()
+	})
 	return nil
 }
 
-func (s *NacosConfigClient) Set(_ context.Context, key, value string) error {
+// Set author from WithAuthor is recorded by publishing with open api
+func (s *NacosConfigClient) Set(ctx context.Context, key, value string) error {
+	if AuthorFromContext(ctx) != "" {
+		success, err := s.publishApi(ctx, key, value, "")
+		if err == nil && !success {
+			err = fmt.Errorf("set key %s fail", key)
+		}
+		return err
+	}
 	// 发布配置
 	success, err := s.client.PublishConfig(
 		vo.ConfigParam{
@@ -86,7 +104,79 @@ func (s *NacosConfigClient) Get(ctx context.Context, key string) (*Item, error)
 	if err != nil {
 		return nil, err
 	}
-	return NewItem(s.conf.Namespace, key, value), nil
+	item := NewItem(s.conf.Namespace, key, value)
+	if value != "" {
+		item.SetRevision(md5Revision(value))
+	}
+	return item, nil
+}
+
+// List blur search dataId with prefix in group
+func (s *NacosConfigClient) List(_ context.Context, prefix string) ([]*Item, error) {
+	var items []*Item
+	for pageNo := 1; ; pageNo++ {
+		page, err := s.client.SearchConfig(vo.SearchConfigParam{
+			Search:   "blur",
+			DataId:   prefix + "*",
+			Group:    s.group,
+			PageNo:   pageNo,
+			PageSize: 100,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, config := range page.PageItems {
+			if !strings.HasPrefix(config.DataId, prefix) {
+				continue
+			}
+			item := NewItem(s.conf.Namespace, config.DataId, config.Content)
+			item.SetRevision(md5Revision(config.Content))
+			items = append(items, item)
+		}
+		if pageNo >= page.PagesAvailable || len(page.PageItems) == 0 {
+			return items, nil
+		}
+	}
+}
+
+func (s *NacosConfigClient) Delete(_ context.Context, key string) error {
+	_, err := s.client.DeleteConfig(vo.ConfigParam{
+		DataId: key,
+		Group:  s.group,
+	})
+	return err
+}
+
+// CompareAndSet publish with md5 cas,empty revision is checked before publishing and not atomic
+func (s *NacosConfigClient) CompareAndSet(ctx context.Context, key, value, revision string) (bool, error) {
+	current, err := s.Get(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if current.GetRevision() != revision {
+		return false, nil
+	}
+	if AuthorFromContext(ctx) != "" {
+		return s.publishApi(ctx, key, value, revision)
+	}
+	success, err := s.client.PublishConfig(vo.ConfigParam{
+		DataId:  key,
+		Group:   s.group,
+		Content: value,
+		CasMd5:  revision,
+	})
+	if err != nil {
+		return false, err
+	}
+	return success, nil
+}
+
+// WatchPrefix nacos has no prefix listening,changes are polled every watch_interval
+func (s *NacosConfigClient) WatchPrefix(ctx context.Context, prefix string, callback func(event *Event)) error {
+	if callback == nil {
+		return nil
+	}
+	return pollPrefix(ctx, s.closeCh, watchInterval(s.conf), prefix, s.List, callback)
 }
 
 func (s *NacosConfigClient) Exist(ctx context.Context, key string) (bool, error) {
@@ -99,6 +189,17 @@ func (s *NacosConfigClient) Exist(ctx context.Context, key string) (bool, error)
 	return value != "", err
 }
 
+// Ping check connectivity by searching one config in group
+func (s *NacosConfigClient) Ping(_ context.Context) error {
+	_, err := s.client.SearchConfig(vo.SearchConfigParam{
+		Search:   "accurate",
+		Group:    s.group,
+		PageNo:   1,
+		PageSize: 1,
+	})
+	return err
+}
+
 func (s *NacosConfigClient) Watch(ctx context.Context, item *Item, callback func(item *Item)) error {
 	return s.client.ListenConfig(vo.ConfigParam{
 		DataId: item.Key,
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/no-mole/neptune/json"
+)
+
+var (
+	_ Historian = &NacosConfigClient{} //ensure NacosConfigClient Implementation Historian
+)
+
+// NacosHistoryTimeout timeout of nacos history open api requests
+var NacosHistoryTimeout = 5 * time.Second
+
+type nacosHistoryItem struct {
+	Id               stdjson.Number     `json:"id"`
+	Content          string             `json:"content"`
+	SrcUser          string             `json:"srcUser"`
+	SrcIp            string             `json:"srcIp"`
+	OpType           string             `json:"opType"`
+	LastModifiedTime stdjson.RawMessage `json:"lastModifiedTime"`
+}
+
+type nacosHistoryPage struct {
+	PageItems []*nacosHistoryItem `json:"pageItems"`
+}
+
+// History nacos config history,Author is the nacos user published the config.
+// Value is the content nacos recorded for the operation,which is the content before it for updates and deletes
+func (s *NacosConfigClient) History(ctx context.Context, key string, limit int) ([]*Revision, error) {
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+	page := &nacosHistoryPage{}
+	err := s.historyApi(ctx, url.Values{
+		"search":   {"accurate"},
+		"dataId":   {key},
+		"group":    {s.group},
+		"pageNo":   {"1"},
+		"pageSize": {strconv.Itoa(limit)},
+	}, page)
+	if err != nil {
+		return nil, err
+	}
+	revisions := make([]*Revision, 0, len(page.PageItems))
+	for _, item := range page.PageItems {
+		//content is not returned by listing
+		detail, err := s.historyDetail(ctx, key, item.Id.String())
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, &Revision{
+			Version: item.Id.String(),
+			Value:   detail.Content,
+			Author:  item.SrcUser,
+			Time:    parseNacosTime(item.LastModifiedTime),
+			Comment: fmt.Sprintf("op %s from %s", item.OpType, item.SrcIp),
+		})
+	}
+	return revisions, nil
+}
+
+// Rollback publish content of history entry version,author from WithAuthor is recorded
+func (s *NacosConfigClient) Rollback(ctx context.Context, key, version string) error {
+	detail, err := s.historyDetail(ctx, key, version)
+	if err != nil {
+		return err
+	}
+	return s.Set(ctx, key, detail.Content)
+}
+
+func (s *NacosConfigClient) historyDetail(ctx context.Context, key, id string) (*nacosHistoryItem, error) {
+	detail := &nacosHistoryItem{}
+	err := s.historyApi(ctx, url.Values{"nid": {id}, "dataId": {key}, "group": {s.group}}, detail)
+	return detail, err
+}
+
+// historyApi call /v1/cs/history of the first server
+func (s *NacosConfigClient) historyApi(ctx context.Context, query url.Values, result any) error {
+	return s.openApi(ctx, http.MethodGet, "/v1/cs/history", query, result)
+}
+
+// publishApi publish config by open api,which records src_user as the author unlike the sdk.
+// nacos with auth enabled records the login user instead
+func (s *NacosConfigClient) publishApi(ctx context.Context, key, value, casMd5 string) (bool, error) {
+	params := url.Values{
+		"dataId":   {key},
+		"group":    {s.group},
+		"content":  {value},
+		"src_user": {AuthorFromContext(ctx)},
+	}
+	if casMd5 != "" {
+		params.Set("casMd5", casMd5)
+	}
+	success := false
+	err := s.openApi(ctx, http.MethodPost, "/v1/cs/configs", params, &success)
+	return success, err
+}
+
+// openApi call open api of the first server,login first when username configured.
+// params are sent as query of GET and form of other methods
+func (s *NacosConfigClient) openApi(ctx context.Context, method, path string, params url.Values, result any) error {
+	if len(s.servers) == 0 {
+		return fmt.Errorf("nacos server not configured")
+	}
+	ctx, cancel := context.WithTimeout(ctx, NacosHistoryTimeout)
+	defer cancel()
+	base := s.serverUrl()
+	params.Set("tenant", s.conf.Namespace)
+	query := url.Values{}
+	if s.conf.Username != "" {
+		token, err := s.login(ctx, base)
+		if err != nil {
+			return err
+		}
+		query.Set("accessToken", token)
+	}
+	var body io.Reader
+	if method == http.MethodGet {
+		for k, v := range params {
+			query[k] = v
+		}
+	} else {
+		body = strings.NewReader(params.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, base+path+"?"+query.Encode(), body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	return doNacosRequest(req, result)
+}
+
+func (s *NacosConfigClient) login(ctx context.Context, base string) (string, error) {
+	form := url.Values{"username": {s.conf.Username}, "password": {s.conf.Password}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"/v1/auth/login", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	result := &struct {
+		AccessToken string `json:"accessToken"`
+	}{}
+	err = doNacosRequest(req, result)
+	return result.AccessToken, err
+}
+
+func (s *NacosConfigClient) serverUrl() string {
+	server := s.servers[0]
+	scheme := server.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+	contextPath := server.ContextPath
+	if contextPath == "" || contextPath == "/" {
+		contextPath = "/nacos"
+	}
+	return fmt.Sprintf("%s://%s:%d%s", scheme, server.IpAddr, server.Port, strings.TrimSuffix(contextPath, "/"))
+}
+
+func doNacosRequest(req *http.Request, result any) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("nacos %s: %s %s", req.URL.Path, resp.Status, body)
+	}
+	return json.Unmarshal(body, result)
+}
+
+// parseNacosTime nacos returns epoch millis or formatted time depending on version
+func parseNacosTime(raw stdjson.RawMessage) time.Time {
+	if ms, err := strconv.ParseInt(string(raw), 10, 64); err == nil {
+		return time.UnixMilli(ms)
+	}
+	var value string
+	if json.Unmarshal(raw, &value) != nil {
+		return time.Time{}
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.000-0700", "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/nacos-group/nacos-sdk-go/v2/common/constant"
+)
+
+func TestNacosHistory(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/nacos/v1/auth/login":
+			_, _ = w.Write([]byte(`{"accessToken":"token"}`))
+		case r.URL.Query().Get("accessToken") != "token" || r.URL.Query().Get("tenant") != "dev":
+			w.WriteHeader(http.StatusForbidden)
+		case r.URL.Query().Get("nid") != "":
+			_, _ = w.Write([]byte(`{"id":"` + r.URL.Query().Get("nid") + `","content":"host: ` + r.URL.Query().Get("nid") + `"}`))
+		default:
+			_, _ = w.Write([]byte(`{"pageItems":[{"id":2,"srcUser":"nacos","opType":"U","lastModifiedTime":1700000000000},{"id":1,"srcUser":"nacos","opType":"I","lastModifiedTime":"2023-11-14T22:13:20.000+00:00"}]}`))
+		}
+	}))
+	defer srv.Close()
+	host, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	p, _ := strconv.ParseUint(port, 10, 64)
+	cli := &NacosConfigClient{
+		group:   "DEFAULT_GROUP",
+		conf:    &Config{Namespace: "dev", Username: "nacos", Password: "nacos"},
+		servers: []constant.ServerConfig{{IpAddr: host, Port: p}},
+	}
+	revisions, err := cli.History(context.Background(), "mysql.yaml", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 || revisions[0].Version != "2" || revisions[0].Value != "host: 2" || revisions[0].Author != "nacos" {
+		t.Fatalf("unexpected revisions %+v", revisions)
+	}
+	if revisions[0].Time.UnixMilli() != 1700000000000 || !revisions[1].Time.Equal(revisions[0].Time) {
+		t.Fatalf("unexpected revision time %s %s", revisions[0].Time, revisions[1].Time)
+	}
+}
+
+func TestNacosRollbackAuthor(t *testing.T) {
+	published := url.Values{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/nacos/v1/auth/login":
+			_, _ = w.Write([]byte(`{"accessToken":"token"}`))
+		case r.URL.Query().Get("accessToken") != "token" || r.FormValue("tenant") != "dev":
+			w.WriteHeader(http.StatusForbidden)
+		case r.Method == http.MethodPost && r.URL.Path == "/nacos/v1/cs/configs":
+			published = r.PostForm
+			_, _ = w.Write([]byte(`true`))
+		default:
+			_, _ = w.Write([]byte(`{"id":"` + r.URL.Query().Get("nid") + `","content":"host: old"}`))
+		}
+	}))
+	defer srv.Close()
+	host, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	p, _ := strconv.ParseUint(port, 10, 64)
+	cli := &NacosConfigClient{
+		group:   "DEFAULT_GROUP",
+		conf:    &Config{Namespace: "dev", Username: "nacos", Password: "nacos"},
+		servers: []constant.ServerConfig{{IpAddr: host, Port: p}},
+	}
+	if err := cli.Rollback(WithAuthor(context.Background(), "alice"), "mysql.yaml", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if published.Get("src_user") != "alice" || published.Get("content") != "host: old" || published.Get("dataId") != "mysql.yaml" || published.Get("group") != "DEFAULT_GROUP" {
+		t.Fatalf("rollback should publish with author,got %v", published)
+	}
+}
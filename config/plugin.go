@@ -4,40 +4,33 @@ import (
 	"context"
 	"errors"
 	"github.com/no-mole/neptune/application"
+	"github.com/no-mole/neptune/health"
 	"github.com/no-mole/neptune/logger"
-	"gopkg.in/yaml.v3"
 )
 
+const pluginName = "config-center"
+
 // NewConfigCenterPlugin 配置中心组件
 func NewConfigCenterPlugin(_ context.Context) application.Plugin {
 	configOpts := &application.PluginConfigOptions{
 		ConfigName: "config.yaml",
 		ConfigType: "yaml",
 		EnvPrefix:  "",
+		FlagPrefix: "config-",
 	}
+	conf := &Config{}
 	plg := &Plugin{
-		Plugin: application.NewPluginConfig("config-center", configOpts),
-		config: &Config{},
+		ConfigPlugin: application.NewConfigPlugin(pluginName, configOpts, conf),
+		config:       conf,
 	}
-
-	plg.Flags().StringVar(&plg.config.Type, "config-type", "", "config client type")
-	plg.Flags().StringVar(&plg.config.Endpoints, "config-endpoints", "", "config client endpoints")
-	plg.Flags().StringVar(&plg.config.Namespace, "config-namespace", "", "config client namespace")
-	plg.Flags().StringVar(&plg.config.Username, "config-username", "", "config client username")
-	plg.Flags().StringVar(&plg.config.Password, "config-password", "", "config client password")
-	plg.Flags().StringToStringVar(&plg.config.Settings, "config-settings", nil, "config client settings")
 	return plg
 }
 
 type Plugin struct {
-	application.Plugin
+	*application.ConfigPlugin[Config]
 	config *Config
 }
 
-func (p *Plugin) Config(_ context.Context, conf []byte) error {
-	return yaml.Unmarshal(conf, p.config)
-}
-
 func (p *Plugin) Init(ctx context.Context) error {
 	logger.Info(
 		ctx,
@@ -51,10 +44,25 @@ func (p *Plugin) Init(ctx context.Context) error {
 	if p.config.Type == "" {
 		return errors.New("config plugin used but not initialization")
 	}
-	return InitDefaultClient(context.Background(), p.config)
+	err := InitDefaultClient(context.Background(), p.config)
+	if err != nil {
+		return err
+	}
+	if pinger, ok := defaultClient.(Pinger); ok {
+		health.Register(p.Name(), p.config.Type, health.CheckerFunc(pinger.Ping))
+	}
+	return nil
 }
 
 func (p *Plugin) Run(ctx context.Context) error {
 	<-ctx.Done()
+	return nil
+}
+
+// Stop close the default client after plugins depending on it stopped
+func (p *Plugin) Stop(_ context.Context) error {
+	if defaultClient == nil {
+		return nil
+	}
 	return defaultClient.Close()
 }
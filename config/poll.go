@@ -0,0 +1,78 @@
+package config
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"time"
+
+	"github.com/no-mole/neptune/logger"
+)
+
+// DefaultWatchInterval poll interval of WatchPrefix for clients without native prefix watching,
+// overridden by the watch_interval setting
+var DefaultWatchInterval = 5 * time.Second
+
+// md5Revision revision of value for clients without native revisions
+func md5Revision(value string) string {
+	sum := md5.Sum([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// watchInterval watch_interval setting or DefaultWatchInterval
+func watchInterval(conf *Config) time.Duration {
+	if conf != nil && conf.Settings != nil {
+		if d, err := time.ParseDuration(conf.Settings["watch_interval"]); err == nil && d > 0 {
+			return d
+		}
+	}
+	return DefaultWatchInterval
+}
+
+// pollPrefix list prefix every interval and call callback with changes compared by revision
+func pollPrefix(ctx context.Context, closeCh <-chan struct{}, interval time.Duration, prefix string,
+	list func(ctx context.Context, prefix string) ([]*Item, error), callback func(event *Event)) error {
+	items, err := list(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	last := make(map[string]*Item, len(items))
+	for _, item := range items {
+		last[item.Key] = item
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-closeCh:
+				return
+			case <-ctx.Done():
+				return
+			}
+			items, err := list(ctx, prefix)
+			if err != nil {
+				logger.Error(ctx, "config watch prefix", err, logger.WithField("prefix", prefix))
+				continue
+			}
+			current := make(map[string]*Item, len(items))
+			for _, item := range items {
+				current[item.Key] = item
+				old, ok := last[item.Key]
+				if !ok || old.GetRevision() != item.GetRevision() {
+					callback(&Event{Type: EventPut, Item: item})
+				}
+			}
+			for key, item := range last {
+				if _, ok := current[key]; !ok {
+					deleted := NewItem(item.Namespace, key, "")
+					deleted.SetDeleted()
+					callback(&Event{Type: EventDelete, Item: deleted})
+				}
+			}
+			last = current
+		}
+	}()
+	return nil
+}
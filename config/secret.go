@@ -0,0 +1,284 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/no-mole/neptune/crypto/aes"
+	"github.com/no-mole/neptune/logger"
+)
+
+// DefaultSecretKeyEnv env var holding the base64 encoded aes key when
+// neither secret_key_env nor secret_key_file setting is configured
+var DefaultSecretKeyEnv = "NEPTUNE_CONFIG_SECRET_KEY"
+
+var ErrSecretKeyNotConfigured = errors.New("config value is encrypted but no secret key configured")
+
+// envelopePattern ENC(base64 ciphertext),may appear anywhere in a value such as password: ENC(...)
+var envelopePattern = regexp.MustCompile(`ENC\(([A-Za-z0-9+/=]+)\)`)
+
+// Cipher encrypt and decrypt config values,implement it to delegate to a KMS
+type Cipher interface {
+	Encrypt(ctx context.Context, plain []byte) ([]byte, error)
+	Decrypt(ctx context.Context, encrypted []byte) ([]byte, error)
+}
+
+// KeyProvider provide the aes key of NewAesCipher
+type KeyProvider interface {
+	Key(ctx context.Context) ([]byte, error)
+}
+
+// KeyProviderFunc adapter to allow the use of ordinary functions as KeyProvider
+type KeyProviderFunc func(ctx context.Context) ([]byte, error)
+
+func (f KeyProviderFunc) Key(ctx context.Context) ([]byte, error) {
+	return f(ctx)
+}
+
+// EnvKeyProvider base64 encoded key read from env var name
+func EnvKeyProvider(name string) KeyProvider {
+	return KeyProviderFunc(func(_ context.Context) ([]byte, error) {
+		value, ok := os.LookupEnv(name)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("secret key env %s is empty", name)
+		}
+		return decodeKey(value)
+	})
+}
+
+// FileKeyProvider base64 encoded key read from file,surrounding whitespace is trimmed
+func FileKeyProvider(filename string) KeyProvider {
+	return KeyProviderFunc(func(_ context.Context) ([]byte, error) {
+		body, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		return decodeKey(string(body))
+	})
+}
+
+func decodeKey(value string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("decode secret key: %w", err)
+	}
+	return key, nil
+}
+
+// NewAesCipher AES-GCM cipher with key from provider,the key is loaded once on first use
+func NewAesCipher(provider KeyProvider) Cipher {
+	return &aesCipher{provider: provider}
+}
+
+type aesCipher struct {
+	provider KeyProvider
+
+	mu  sync.Mutex
+	key []byte
+}
+
+func (c *aesCipher) Encrypt(ctx context.Context, plain []byte) ([]byte, error) {
+	key, err := c.getKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return aes.EncryptGCM(plain, key)
+}
+
+func (c *aesCipher) Decrypt(ctx context.Context, encrypted []byte) ([]byte, error) {
+	key, err := c.getKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return aes.DecryptGCM(encrypted, key)
+}
+
+func (c *aesCipher) getKey(ctx context.Context) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.key != nil {
+		return c.key, nil
+	}
+	key, err := c.provider.Key(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.key = key
+	return key, nil
+}
+
+var defaultCipher Cipher
+
+// SetDefaultCipher cipher used by the default client,such as a KMS cipher.
+// Must be called before the config center plugin init
+func SetDefaultCipher(c Cipher) {
+	defaultCipher = c
+}
+
+// CipherFromConfig SetDefaultCipher cipher,or aes cipher with key from secret_key_file setting,
+// secret_key_env setting or DefaultSecretKeyEnv.returns nil when no key configured
+func CipherFromConfig(conf *Config) Cipher {
+	if defaultCipher != nil {
+		return defaultCipher
+	}
+	if filename := conf.Settings["secret_key_file"]; filename != "" {
+		return NewAesCipher(FileKeyProvider(filename))
+	}
+	env := DefaultSecretKeyEnv
+	if name := conf.Settings["secret_key_env"]; name != "" {
+		env = name
+	}
+	if os.Getenv(env) == "" {
+		return nil
+	}
+	return NewAesCipher(EnvKeyProvider(env))
+}
+
+// EncryptValue encrypt plain into the ENC(...) envelope
+func EncryptValue(ctx context.Context, c Cipher, plain string) (string, error) {
+	encrypted, err := c.Encrypt(ctx, []byte(plain))
+	if err != nil {
+		return "", err
+	}
+	return "ENC(" + base64.StdEncoding.EncodeToString(encrypted) + ")", nil
+}
+
+// IsEncrypted value contains ENC(...) envelope
+func IsEncrypted(value string) bool {
+	return envelopePattern.MatchString(value)
+}
+
+// DecryptValue replace every ENC(...) envelope in value with its plain text,
+// values without envelope are returned as is.c may be nil when value is not encrypted
+func DecryptValue(ctx context.Context, c Cipher, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	if c == nil {
+		return "", ErrSecretKeyNotConfigured
+	}
+	var decryptErr error
+	plain := envelopePattern.ReplaceAllStringFunc(value, func(envelope string) string {
+		if decryptErr != nil {
+			return envelope
+		}
+		encrypted, err := base64.StdEncoding.DecodeString(envelopePattern.FindStringSubmatch(envelope)[1])
+		if err != nil {
+			decryptErr = err
+			return envelope
+		}
+		body, err := c.Decrypt(ctx, encrypted)
+		if err != nil {
+			decryptErr = err
+			return envelope
+		}
+		return string(body)
+	})
+	if decryptErr != nil {
+		return "", fmt.Errorf("decrypt config value: %w", decryptErr)
+	}
+	return plain, nil
+}
+
+var (
+	_ Client = &SecretClient{} //ensure SecretClient Implementation Client
+)
+
+// NewSecretClient wrap client to decrypt ENC(...) envelopes of values returned by Get,List and watches.
+// Values are written as is,encrypt them by EncryptValue before Set
+func NewSecretClient(client Client, c Cipher) *SecretClient {
+	return &SecretClient{Client: client, cipher: c}
+}
+
+type SecretClient struct {
+	Client
+	cipher Cipher
+}
+
+func (s *SecretClient) Get(ctx context.Context, key string) (*Item, error) {
+	item, err := s.Client.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return item, s.decrypt(ctx, item)
+}
+
+func (s *SecretClient) List(ctx context.Context, prefix string) ([]*Item, error) {
+	items, err := s.Client.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if err = s.decrypt(ctx, item); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// Watch values failed to decrypt are not delivered,watch the raw item of inner client to
+// keep change detection working on ciphertext
+func (s *SecretClient) Watch(ctx context.Context, item *Item, callback func(item *Item)) error {
+	if callback == nil {
+		return nil
+	}
+	raw, err := s.Client.Get(ctx, item.Key)
+	if err != nil {
+		return err
+	}
+	return s.Client.Watch(ctx, raw, func(raw *Item) {
+		if raw.IsDeleted() {
+			item.SetDeleted()
+			callback(item)
+			return
+		}
+		value, err := DecryptValue(ctx, s.cipher, raw.GetValue())
+		if err != nil {
+			logger.Error(ctx, "config decrypt", err, logger.WithField("key", raw.Key))
+			return
+		}
+		item.SetValue(value)
+		item.SetRevision(raw.GetRevision())
+		callback(item)
+	})
+}
+
+func (s *SecretClient) WatchPrefix(ctx context.Context, prefix string, callback func(event *Event)) error {
+	if callback == nil {
+		return nil
+	}
+	return s.Client.WatchPrefix(ctx, prefix, func(event *Event) {
+		if err := s.decrypt(ctx, event.Item); err != nil {
+			logger.Error(ctx, "config decrypt", err, logger.WithField("key", event.Item.Key))
+			return
+		}
+		callback(event)
+	})
+}
+
+func (s *SecretClient) Unwrap() Client {
+	return s.Client
+}
+
+// Ping ping inner client if it implements Pinger
+func (s *SecretClient) Ping(ctx context.Context) error {
+	if pinger, ok := s.Client.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (s *SecretClient) decrypt(ctx context.Context, item *Item) error {
+	value, err := DecryptValue(ctx, s.cipher, item.GetValue())
+	if err != nil {
+		return fmt.Errorf("config key [%s]: %w", item.Key, err)
+	}
+	item.SetValue(value)
+	return nil
+}
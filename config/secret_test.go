@@ -0,0 +1,45 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestSecretClient(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	key := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+	t.Setenv("TEST_CONFIG_SECRET_KEY", key)
+	c := NewAesCipher(EnvKeyProvider("TEST_CONFIG_SECRET_KEY"))
+	encrypted, err := EncryptValue(ctx, c, "p@ss")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mem := NewMemoryConfigClient(map[string]string{"mysql.yaml": "user: root\npassword: " + encrypted})
+	cli := NewSecretClient(mem, c)
+	item, err := cli.Get(ctx, "mysql.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.GetValue() != "user: root\npassword: p@ss" {
+		t.Fatalf("unexpected decrypted value %q", item.GetValue())
+	}
+	changed := make(chan string, 1)
+	err = cli.Watch(ctx, item, func(item *Item) {
+		changed <- item.GetValue()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, _ = EncryptValue(ctx, c, "new")
+	_ = mem.Set(ctx, "mysql.yaml", "password: "+encrypted)
+	if value := <-changed; value != "password: new" {
+		t.Fatalf("unexpected watched value %q", value)
+	}
+	_, err = NewSecretClient(mem, nil).Get(ctx, "mysql.yaml")
+	if !errors.Is(err, ErrSecretKeyNotConfigured) {
+		t.Fatalf("expected ErrSecretKeyNotConfigured,got %v", err)
+	}
+}
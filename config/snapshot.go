@@ -0,0 +1,505 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/no-mole/neptune/health"
+	"github.com/no-mole/neptune/json"
+	"github.com/no-mole/neptune/logger"
+)
+
+var (
+	// DefaultSnapshotRetryInterval interval of reconnecting config center while serving snapshot,
+	// overridden by the snapshot_retry_interval setting
+	DefaultSnapshotRetryInterval = 10 * time.Second
+
+	ErrConfigCenterOffline = errors.New("config center offline,serving local snapshot")
+)
+
+var (
+	_ Client = &SnapshotClient{} //ensure SnapshotClient Implementation Client
+)
+
+// NewSnapshotClient persist every fetched item to dir and serve them when the config center is unreachable.
+// When Init of the client created by fn failed with an existing snapshot,the client starts offline
+// and keeps reconnecting in background,watches registered meanwhile are re-synced once the center returns.
+//
+// Settings:
+//
+//	snapshot_retry_interval: reconnect interval while offline,default 10s
+//	snapshot_max_staleness: Ping fails when offline longer than it,default unlimited
+func NewSnapshotClient(fn RegistryImplementationFunc, dir string) *SnapshotClient {
+	return &SnapshotClient{fn: fn, dir: dir}
+}
+
+type SnapshotClient struct {
+	fn      RegistryImplementationFunc
+	initial Client //client already created by the caller,used by the first connect instead of fn
+	dir     string
+	config  *Config
+
+	mu         sync.RWMutex
+	client     Client //nil while offline
+	staleSince time.Time
+	watches    []func(client Client) //deferred watches attached when online
+	closeCh    chan struct{}
+	closeOnce  sync.Once
+}
+
+type snapshotItem struct {
+	Key       string    `json:"key"`
+	Value     string    `json:"value"`
+	Revision  string    `json:"revision"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+func (s *SnapshotClient) Init(ctx context.Context, conf *Config) error {
+	s.config = conf
+	s.closeCh = make(chan struct{})
+	client, err := s.connect(ctx)
+	if err == nil {
+		s.client = client
+		return nil
+	}
+	if !s.hasSnapshot() {
+		return err
+	}
+	logger.Error(ctx, "config center unreachable,serving local snapshot", err, logger.WithField("dir", s.namespaceDir()))
+	s.markStale()
+	go s.reconnect(ctx)
+	return nil
+}
+
+// connect create and init a client,Ping it when supported since some clients connect lazily
+func (s *SnapshotClient) connect(ctx context.Context) (Client, error) {
+	client := s.initial
+	s.initial = nil
+	if client == nil {
+		client = s.fn(ctx)
+	}
+	err := client.Init(ctx, s.config)
+	if err == nil {
+		if pinger, ok := client.(Pinger); ok {
+			err = pinger.Ping(ctx)
+		}
+	}
+	if err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+func (s *SnapshotClient) reconnect(ctx context.Context) {
+	interval := DefaultSnapshotRetryInterval
+	if d, err := time.ParseDuration(s.config.Settings["snapshot_retry_interval"]); err == nil && d > 0 {
+		interval = d
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.closeCh:
+			return
+		case <-ctx.Done():
+			return
+		}
+		client, err := s.connect(ctx)
+		if err != nil {
+			logger.Error(ctx, "config center reconnect", err)
+			continue
+		}
+		s.mu.Lock()
+		s.client = client
+		watches := s.watches
+		s.watches = nil
+		s.mu.Unlock()
+		logger.Info(ctx, "config center reconnected,resync from center")
+		for _, watch := range watches {
+			watch(client)
+		}
+		s.markFresh()
+		return
+	}
+}
+
+func (s *SnapshotClient) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.closeCh)
+	})
+	if client := s.getClient(); client != nil {
+		return client.Close()
+	}
+	return nil
+}
+
+// Unwrap the config center client,nil while offline
+func (s *SnapshotClient) Unwrap() Client {
+	return s.getClient()
+}
+
+func (s *SnapshotClient) getClient() Client {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.client
+}
+
+func (s *SnapshotClient) Set(ctx context.Context, key, value string) error {
+	client := s.getClient()
+	if client == nil {
+		return ErrConfigCenterOffline
+	}
+	return client.Set(ctx, key, value)
+}
+
+func (s *SnapshotClient) Delete(ctx context.Context, key string) error {
+	client := s.getClient()
+	if client == nil {
+		return ErrConfigCenterOffline
+	}
+	err := client.Delete(ctx, key)
+	if err == nil {
+		s.remove(ctx, key)
+	}
+	return err
+}
+
+func (s *SnapshotClient) CompareAndSet(ctx context.Context, key, value, revision string) (bool, error) {
+	client := s.getClient()
+	if client == nil {
+		return false, ErrConfigCenterOffline
+	}
+	return client.CompareAndSet(ctx, key, value, revision)
+}
+
+// Get fetch from config center and persist it,fallback to snapshot when the center failed
+func (s *SnapshotClient) Get(ctx context.Context, key string) (*Item, error) {
+	client := s.getClient()
+	if client != nil {
+		item, err := client.Get(ctx, key)
+		if err == nil {
+			s.markFresh()
+			s.save(ctx, item)
+			return item, nil
+		}
+		logger.Error(ctx, "config center get,fallback to snapshot", err, logger.WithField("key", key))
+		s.markStale()
+	}
+	snapshot, err := s.load(key)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot == nil {
+		return nil, fmt.Errorf("config key [%s] not found in snapshot: %w", key, ErrConfigCenterOffline)
+	}
+	return s.item(snapshot), nil
+}
+
+func (s *SnapshotClient) List(ctx context.Context, prefix string) ([]*Item, error) {
+	client := s.getClient()
+	if client != nil {
+		items, err := client.List(ctx, prefix)
+		if err == nil {
+			s.markFresh()
+			for _, item := range items {
+				s.save(ctx, item)
+			}
+			return items, nil
+		}
+		logger.Error(ctx, "config center list,fallback to snapshot", err, logger.WithField("prefix", prefix))
+		s.markStale()
+	}
+	snapshots, err := s.loadPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*Item, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		items = append(items, s.item(snapshot))
+	}
+	return items, nil
+}
+
+func (s *SnapshotClient) Exist(ctx context.Context, key string) (bool, error) {
+	client := s.getClient()
+	if client != nil {
+		exist, err := client.Exist(ctx, key)
+		if err == nil {
+			return exist, nil
+		}
+		s.markStale()
+	}
+	snapshot, err := s.load(key)
+	return snapshot != nil, err
+}
+
+// Watch while offline the watch is deferred until reconnected,
+// then callback is called if the value changed compared with the snapshot
+func (s *SnapshotClient) Watch(ctx context.Context, item *Item, callback func(item *Item)) error {
+	if callback == nil {
+		return nil
+	}
+	watch := func(ctx context.Context, client Client) error {
+		return client.Watch(ctx, item, func(item *Item) {
+			if item.IsDeleted() {
+				s.remove(ctx, item.Key)
+			} else {
+				s.save(ctx, item)
+			}
+			callback(item)
+		})
+	}
+	s.mu.Lock()
+	client := s.client
+	if client == nil {
+		s.watches = append(s.watches, func(client Client) {
+			if ctx.Err() != nil {
+				return
+			}
+			//watch before resync,changes made right after the resync callback are not missed
+			if err := watch(ctx, client); err != nil {
+				logger.Error(ctx, "config center resync watch", err, logger.WithField("key", item.Key))
+			}
+			current, err := client.Get(ctx, item.Key)
+			if err != nil {
+				logger.Error(ctx, "config center resync", err, logger.WithField("key", item.Key))
+				return
+			}
+			s.save(ctx, current)
+			if current.GetValue() != item.GetValue() {
+				item.SetValue(current.GetValue())
+				item.SetRevision(current.GetRevision())
+				callback(item)
+			} else {
+				item.SetRevision(current.GetRevision())
+			}
+		})
+	}
+	s.mu.Unlock()
+	if client == nil {
+		return nil
+	}
+	return watch(ctx, client)
+}
+
+// WatchPrefix while offline the watch is deferred until reconnected,
+// then callback is called with changes compared with the snapshot
+func (s *SnapshotClient) WatchPrefix(ctx context.Context, prefix string, callback func(event *Event)) error {
+	if callback == nil {
+		return nil
+	}
+	watch := func(ctx context.Context, client Client) error {
+		return client.WatchPrefix(ctx, prefix, func(event *Event) {
+			if event.Type == EventDelete {
+				s.remove(ctx, event.Item.Key)
+			} else {
+				s.save(ctx, event.Item)
+			}
+			callback(event)
+		})
+	}
+	s.mu.Lock()
+	client := s.client
+	if client == nil {
+		s.watches = append(s.watches, func(client Client) {
+			if ctx.Err() != nil {
+				return
+			}
+			//watch before resync,changes made right after the resync callbacks are not missed
+			if err := watch(ctx, client); err != nil {
+				logger.Error(ctx, "config center resync watch", err, logger.WithField("prefix", prefix))
+			}
+			snapshots, err := s.loadPrefix(prefix)
+			if err != nil {
+				logger.Error(ctx, "config snapshot load", err, logger.WithField("prefix", prefix))
+			}
+			items, err := client.List(ctx, prefix)
+			if err != nil {
+				logger.Error(ctx, "config center resync", err, logger.WithField("prefix", prefix))
+				return
+			}
+			last := make(map[string]*snapshotItem, len(snapshots))
+			for _, snapshot := range snapshots {
+				last[snapshot.Key] = snapshot
+			}
+			for _, item := range items {
+				s.save(ctx, item)
+				if old, ok := last[item.Key]; !ok || old.Value != item.GetValue() {
+					callback(&Event{Type: EventPut, Item: item})
+				}
+				delete(last, item.Key)
+			}
+			for key := range last {
+				s.remove(ctx, key)
+				deleted := NewItem(s.config.Namespace, key, "")
+				deleted.SetDeleted()
+				callback(&Event{Type: EventDelete, Item: deleted})
+			}
+		})
+	}
+	s.mu.Unlock()
+	if client == nil {
+		return nil
+	}
+	return watch(ctx, client)
+}
+
+// Ping succeed while serving snapshot unless offline longer than snapshot_max_staleness
+func (s *SnapshotClient) Ping(ctx context.Context) error {
+	s.mu.RLock()
+	client, staleSince := s.client, s.staleSince
+	s.mu.RUnlock()
+	if client != nil {
+		pinger, ok := client.(Pinger)
+		if !ok {
+			return nil
+		}
+		err := pinger.Ping(ctx)
+		if err == nil {
+			s.markFresh()
+			return nil
+		}
+		s.markStale()
+		if !s.hasSnapshot() {
+			return err
+		}
+		s.mu.RLock()
+		staleSince = s.staleSince
+		s.mu.RUnlock()
+	}
+	if maxStaleness, err := time.ParseDuration(s.config.Settings["snapshot_max_staleness"]); err == nil && maxStaleness > 0 &&
+		time.Since(staleSince) > maxStaleness {
+		return fmt.Errorf("%w since %s", ErrConfigCenterOffline, staleSince.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Stale the time since which values are served from snapshot,zero when the center is reachable
+func (s *SnapshotClient) Stale() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.staleSince
+}
+
+func (s *SnapshotClient) markStale() {
+	s.mu.Lock()
+	if !s.staleSince.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	s.staleSince = time.Now()
+	since := s.staleSince.Format(time.RFC3339)
+	s.mu.Unlock()
+	health.SetDetail(pluginName, "snapshot", "stale since "+since)
+}
+
+func (s *SnapshotClient) markFresh() {
+	s.mu.Lock()
+	if s.staleSince.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	s.staleSince = time.Time{}
+	s.mu.Unlock()
+	health.SetDetail(pluginName, "snapshot", "")
+}
+
+func (s *SnapshotClient) namespaceDir() string {
+	return filepath.Join(s.dir, url.PathEscape(s.config.Namespace))
+}
+
+func (s *SnapshotClient) filename(key string) string {
+	return filepath.Join(s.namespaceDir(), url.PathEscape(key)+".json")
+}
+
+func (s *SnapshotClient) hasSnapshot() bool {
+	entries, err := os.ReadDir(s.namespaceDir())
+	return err == nil && len(entries) > 0
+}
+
+func (s *SnapshotClient) item(snapshot *snapshotItem) *Item {
+	item := NewItem(s.config.Namespace, snapshot.Key, snapshot.Value)
+	item.SetRevision(snapshot.Revision)
+	return item
+}
+
+// save persist item,failures are logged only since the snapshot is best effort
+func (s *SnapshotClient) save(ctx context.Context, item *Item) {
+	body, err := json.Marshal(&snapshotItem{
+		Key:       item.Key,
+		Value:     item.GetValue(),
+		Revision:  item.GetRevision(),
+		FetchedAt: time.Now(),
+	})
+	if err == nil {
+		err = os.MkdirAll(s.namespaceDir(), 0700)
+	}
+	if err == nil {
+		err = writeFile(s.filename(item.Key), body)
+	}
+	if err != nil {
+		logger.Error(ctx, "config snapshot save", err, logger.WithField("key", item.Key))
+	}
+}
+
+func (s *SnapshotClient) remove(ctx context.Context, key string) {
+	err := os.Remove(s.filename(key))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.Error(ctx, "config snapshot remove", err, logger.WithField("key", key))
+	}
+}
+
+// load snapshot of key,nil when not exist
+func (s *SnapshotClient) load(key string) (*snapshotItem, error) {
+	body, err := os.ReadFile(s.filename(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &snapshotItem{}
+	return snapshot, json.Unmarshal(body, snapshot)
+}
+
+func (s *SnapshotClient) loadPrefix(prefix string) ([]*snapshotItem, error) {
+	entries, err := os.ReadDir(s.namespaceDir())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []*snapshotItem
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		key, err := url.PathUnescape(strings.TrimSuffix(name, ".json"))
+		if err != nil || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		snapshot, err := s.load(key)
+		if err != nil {
+			return nil, err
+		}
+		if snapshot != nil {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Key < snapshots[j].Key
+	})
+	return snapshots, nil
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type flakyConfigClient struct {
+	*MemoryConfigClient
+	down *atomic.Bool
+}
+
+func (f *flakyConfigClient) Init(ctx context.Context, conf *Config) error {
+	if f.down.Load() {
+		return errors.New("connection refused")
+	}
+	return f.MemoryConfigClient.Init(ctx, conf)
+}
+
+func TestSnapshotClient(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mem := NewMemoryConfigClient(map[string]string{"redis.yaml": "addr: a"})
+	down := &atomic.Bool{}
+	fn := func(ctx context.Context) Client {
+		return &flakyConfigClient{MemoryConfigClient: mem, down: down}
+	}
+	dir := t.TempDir()
+	conf := &Config{Type: "memory", Namespace: "dev", Settings: map[string]string{"snapshot_retry_interval": "10ms"}}
+
+	cli := NewSnapshotClient(fn, dir)
+	if err := cli.Init(ctx, conf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.Get(ctx, "redis.yaml"); err != nil {
+		t.Fatal(err)
+	}
+
+	//restart while config center is down
+	down.Store(true)
+	cli = NewSnapshotClient(fn, dir)
+	if err := cli.Init(ctx, conf); err != nil {
+		t.Fatalf("init with snapshot failed:%v", err)
+	}
+	item, err := cli.Get(ctx, "redis.yaml")
+	if err != nil || item.GetValue() != "addr: a" {
+		t.Fatalf("unexpected snapshot value %v %v", item, err)
+	}
+	if cli.Stale().IsZero() {
+		t.Fatal("expected stale snapshot")
+	}
+	if err = cli.Set(ctx, "redis.yaml", "addr: b"); !errors.Is(err, ErrConfigCenterOffline) {
+		t.Fatalf("expected ErrConfigCenterOffline,got %v", err)
+	}
+	changed := make(chan string, 2)
+	err = cli.Watch(ctx, item, func(item *Item) {
+		changed <- item.GetValue()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//value changed while offline is delivered after reconnected,then watched as usual
+	_ = mem.Set(ctx, "redis.yaml", "addr: b")
+	down.Store(false)
+	for _, want := range []string{"addr: b", "addr: c"} {
+		select {
+		case value := <-changed:
+			if value != want {
+				t.Fatalf("unexpected watched value %q,want %q", value, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("watch timeout")
+		}
+		if want == "addr: b" {
+			_ = cli.Set(ctx, "redis.yaml", "addr: c")
+		}
+	}
+	if !cli.Stale().IsZero() {
+		t.Fatal("expected fresh after reconnected")
+	}
+}
+
+func TestInitDefaultClientSnapshot(t *testing.T) {
+	created := 0
+	RegistryImplementation("snapshot-test", func(ctx context.Context) Client {
+		created++
+		return NewMemoryConfigClient(map[string]string{"redis.yaml": "addr: a"})
+	})
+	defer delete(configCenterImplementation, "snapshot-test")
+	conf := &Config{Type: "snapshot-test", Namespace: "dev", Settings: map[string]string{"snapshot_dir": t.TempDir()}}
+	if err := InitDefaultClient(context.Background(), conf); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = Close() }()
+	if created != 1 {
+		t.Fatalf("client should be created once,got %d", created)
+	}
+	item, err := Get(context.Background(), "redis.yaml")
+	if err != nil || item.GetValue() != "addr: a" {
+		t.Fatalf("unexpected value %v %v", item, err)
+	}
+}
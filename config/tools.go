@@ -2,6 +2,7 @@ package config
 
 import (
 	"context"
+	consulapi "github.com/hashicorp/consul/api"
 	"github.com/nacos-group/nacos-sdk-go/v2/common/constant"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"net/url"
@@ -84,6 +85,29 @@ func Trans2NacosConfig(_ context.Context, config *Config) (clientConfig *constan
 	}
 	return
 }
+
+// Trans2ConsulConfig first endpoint as agent address such as http://127.0.0.1:8500,
+// password as acl token or basic auth password with username,and datacenter setting
+func Trans2ConsulConfig(_ context.Context, config *Config) *consulapi.Config {
+	consulConf := consulapi.DefaultConfig()
+	if ep := strings.Split(config.Endpoints, ",")[0]; ep != "" {
+		if u, err := url.Parse(ep); err == nil && u.Host != "" {
+			consulConf.Scheme, consulConf.Address = u.Scheme, u.Host
+		} else {
+			consulConf.Address = ep
+		}
+	}
+	if config.Username != "" {
+		consulConf.HttpAuth = &consulapi.HttpBasicAuth{Username: config.Username, Password: config.Password}
+	} else if config.Password != "" {
+		consulConf.Token = config.Password
+	}
+	if datacenter, ok := config.Settings["datacenter"]; ok {
+		consulConf.Datacenter = datacenter
+	}
+	return consulConf
+}
+
 func getHostAndPort(rawurl string) (scheme, host string, port uint64, path string, err error) {
 	u, err := url.Parse(rawurl)
 	if err != nil {
@@ -8,7 +8,9 @@ import (
 	"time"
 
 	validate "github.com/go-playground/validator/v10"
+	"github.com/no-mole/neptune/health"
 	"github.com/no-mole/neptune/logger"
+	"github.com/no-mole/neptune/metrics"
 	"gorm.io/gorm"
 	gormLogger "gorm.io/gorm/logger"
 	"gorm.io/gorm/schema"
@@ -47,15 +49,22 @@ type BaseDb struct {
 }
 
 func (b *BaseDb) SetEngine(ctx context.Context, engine string) bool {
-	if value, ok := databases.Load(engine); ok {
-		if db, ok := value.(*gorm.DB); ok {
-			b.DB = db.WithContext(ctx)
-			return true
-		}
+	if db, ok := GetDB(engine); ok {
+		b.DB = db.WithContext(ctx)
+		return true
 	}
 	return false
 }
 
+// GetDB db of dbName,the stored db is replaced instead of modified on re-init
+func GetDB(dbName string) (*gorm.DB, bool) {
+	if value, ok := databases.Load(dbName); ok {
+		db, ok := value.(*gorm.DB)
+		return db, ok
+	}
+	return nil, false
+}
+
 type option struct {
 	maxIdleConn  int
 	maxOpenConn  int
@@ -112,7 +121,7 @@ func Init(dbName string, conf *Config) error {
 		WithMaxIdleConn(conf.MaxIdleConnes),
 		WithMaxOpenConn(conf.MaxOpenConnes),
 		WithMaxLifetime(time.Duration(conf.MaxLifetime)*time.Second),
-		WithPlugins(),
+		WithPlugins(conf.Plugins...),
 	)
 	if err != nil {
 		return err
@@ -129,6 +138,11 @@ func Init(dbName string, conf *Config) error {
 	}
 
 	databases.Store(dbName, db)
+	err = metrics.RegisterDBStats(dbName, dbInstance)
+	if err != nil {
+		return err
+	}
+	health.Register("database", dbName, health.CheckerFunc(dbInstance.PingContext))
 
 	return nil
 }
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"time"
+
+	"github.com/no-mole/neptune/metrics"
+	"gorm.io/gorm"
+)
+
+func init() {
+	RegisterPlugin("metrics", Metrics)
+}
+
+const metricsStartKey = "neptune:metrics_start"
+
+// Metrics gorm plugin recording latency and errors of statements,enabled by `plugins: [metrics]` in database config
+func Metrics(conf *Config) gorm.Plugin {
+	return &metricsPlugin{db: conf.Database}
+}
+
+type metricsPlugin struct {
+	db string
+}
+
+func (m *metricsPlugin) Name() string {
+	return "metrics"
+}
+
+func (m *metricsPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	hooks := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+	for _, hook := range hooks {
+		err := hook.before("metrics:before_"+hook.operation, m.before)
+		if err != nil {
+			return err
+		}
+		err = hook.after("metrics:after_"+hook.operation, m.after(hook.operation))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *metricsPlugin) before(db *gorm.DB) {
+	db.InstanceSet(metricsStartKey, time.Now())
+}
+
+func (m *metricsPlugin) after(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(metricsStartKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		metrics.DbQuerySeconds.WithLabelValues(m.db, operation, table).Observe(time.Since(start).Seconds())
+		if db.Error != nil && db.Error != gorm.ErrRecordNotFound {
+			metrics.DbQueryErrors.WithLabelValues(m.db, operation, table).Inc()
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package datasource
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/no-mole/neptune/config"
+	"github.com/no-mole/neptune/database"
+	"github.com/no-mole/neptune/elastic_search"
+	"github.com/no-mole/neptune/mongo"
+	"github.com/no-mole/neptune/rabbitmq"
+	"github.com/no-mole/neptune/redis"
+)
+
+const (
+	KindMysql         = "mysql"    //database package,driver defaults to mysql
+	KindDatabase      = "database" //database package,driver from config
+	KindRedis         = "redis"
+	KindMongo         = "mongo"
+	KindElasticSearch = "es"
+	KindRabbitMq      = "rabbitmq"
+)
+
+// Initializer init client of name from config value and store it replacing the previous one,
+// the replaced client is returned to be closed after in-flight requests finished,nil when there is none
+type Initializer interface {
+	Init(ctx context.Context, name string, value []byte, codec config.Codec) (replaced io.Closer, err error)
+}
+
+// InitializerFunc adapter to allow the use of ordinary functions as Initializer
+type InitializerFunc func(ctx context.Context, name string, value []byte, codec config.Codec) (io.Closer, error)
+
+func (f InitializerFunc) Init(ctx context.Context, name string, value []byte, codec config.Codec) (io.Closer, error) {
+	return f(ctx, name, value, codec)
+}
+
+// CloserFunc adapter to allow the use of ordinary functions as io.Closer
+type CloserFunc func() error
+
+func (f CloserFunc) Close() error {
+	return f()
+}
+
+var (
+	kinds   = map[string]Initializer{}
+	kindsMu sync.RWMutex
+)
+
+func init() {
+	RegisterKind(KindMysql, InitializerFunc(initDatabase(KindMysql)))
+	RegisterKind(KindDatabase, InitializerFunc(initDatabase("")))
+	RegisterKind(KindRedis, InitializerFunc(initRedis))
+	RegisterKind(KindMongo, InitializerFunc(initMongo))
+	RegisterKind(KindElasticSearch, InitializerFunc(initElasticSearch))
+	RegisterKind(KindRabbitMq, InitializerFunc(initRabbitMq))
+}
+
+// RegisterKind register initializer of kind,replacing the builtin one with the same kind
+func RegisterKind(kind string, initializer Initializer) {
+	kindsMu.Lock()
+	defer kindsMu.Unlock()
+	kinds[kind] = initializer
+}
+
+func getKind(kind string) (Initializer, error) {
+	kindsMu.RLock()
+	defer kindsMu.RUnlock()
+	initializer, ok := kinds[kind]
+	if !ok {
+		return nil, fmt.Errorf("datasource kind [%s] not registered", kind)
+	}
+	return initializer, nil
+}
+
+func initDatabase(driver string) InitializerFunc {
+	return func(_ context.Context, name string, value []byte, codec config.Codec) (io.Closer, error) {
+		conf := &database.Config{Driver: driver}
+		if err := codec.Unmarshal(value, conf); err != nil {
+			return nil, err
+		}
+		old, exist := database.GetDB(name)
+		if err := database.Init(name, conf); err != nil {
+			return nil, err
+		}
+		if !exist {
+			return nil, nil
+		}
+		return CloserFunc(func() error {
+			db, err := old.DB()
+			if err != nil {
+				return err
+			}
+			return db.Close()
+		}), nil
+	}
+}
+
+func initRedis(_ context.Context, name string, value []byte, codec config.Codec) (io.Closer, error) {
+	conf := &redis.Config{}
+	if err := codec.Unmarshal(value, conf); err != nil {
+		return nil, err
+	}
+	old, exist := redis.Client.GetClient(name)
+	if err := redis.InitWithConfig(name, conf); err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, nil
+	}
+	return old, nil
+}
+
+func initMongo(ctx context.Context, name string, value []byte, codec config.Codec) (io.Closer, error) {
+	conf := &mongo.MongoConfig{}
+	if err := codec.Unmarshal(value, conf); err != nil {
+		return nil, err
+	}
+	old, exist := mongo.GetClient(name)
+	if err := mongo.InitMonClientWithConfig(ctx, name, conf); err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, nil
+	}
+	return CloserFunc(func() error {
+		return old.Disconnect(context.Background())
+	}), nil
+}
+
+func initElasticSearch(_ context.Context, name string, value []byte, codec config.Codec) (io.Closer, error) {
+	conf := &elastic_search.Config{}
+	if err := codec.Unmarshal(value, conf); err != nil {
+		return nil, err
+	}
+	old, exist := elastic_search.Client.GetClient(name)
+	if err := elastic_search.InitElasticSearchWithConfig(name, conf); err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, nil
+	}
+	return CloserFunc(func() error {
+		old.Stop()
+		return nil
+	}), nil
+}
+
+func initRabbitMq(_ context.Context, name string, value []byte, codec config.Codec) (io.Closer, error) {
+	conf := &rabbitmq.Config{}
+	if err := codec.Unmarshal(value, conf); err != nil {
+		return nil, err
+	}
+	//load the stored connection,GetClient would reconnect a closed one
+	old, exist := rabbitmq.Client.Load(name)
+	if err := rabbitmq.InitRabbitMqWithConfig(name, conf); err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, nil
+	}
+	closer, ok := old.(io.Closer)
+	if !ok {
+		return nil, nil
+	}
+	return closer, nil
+}
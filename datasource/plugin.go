@@ -0,0 +1,173 @@
+package datasource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/no-mole/neptune/application"
+	"github.com/no-mole/neptune/config"
+	"github.com/no-mole/neptune/logger"
+	"go.uber.org/zap"
+)
+
+// Source client declared by the app,Name is the client name used by the kind package such as redis.Client.GetClient(name)
+type Source struct {
+	Name string
+	Key  string //config center key,decoded by key extension
+	Kind string
+}
+
+// NewPlugin init clients of sources from config center values and rebuild them when the values changed.
+// The rebuilt client replaces the old one atomically,the old one is closed after close delay so in-flight requests finish
+func NewPlugin(sources ...Source) *Plugin {
+	conf := &Conf{}
+	return &Plugin{
+		ConfigPlugin: application.NewConfigPlugin("datasource", &application.PluginConfigOptions{
+			ConfigName: "app.yaml",
+			ConfigType: "yaml",
+		}, conf),
+		conf:    conf,
+		sources: sources,
+		pending: map[*time.Timer]*replacedClient{},
+	}
+}
+
+type Plugin struct {
+	*application.ConfigPlugin[Conf] `yaml:"-" json:"-"`
+
+	conf    *Conf
+	sources []Source
+
+	//pending old clients waiting to be closed
+	mu      sync.Mutex
+	pending map[*time.Timer]*replacedClient
+}
+
+type replacedClient struct {
+	source Source
+	closer io.Closer
+}
+
+type Conf struct {
+	CloseDelay time.Duration `json:"datasource-close-delay" yaml:"datasource-close-delay" flag:"datasource-close-delay" default:"30s" usage:"delay of closing replaced clients,default is 30s" validate:"gte=0"`
+}
+
+// Dependencies config values are read by the default config client
+func (p *Plugin) Dependencies() []string {
+	return []string{"config-center"}
+}
+
+func (p *Plugin) ValidateConfig() error {
+	seen := map[string]bool{}
+	for _, source := range p.sources {
+		if source.Name == "" || source.Key == "" {
+			return errors.New("datasource name and key are required")
+		}
+		if _, err := getKind(source.Kind); err != nil {
+			return err
+		}
+		id := source.Kind + "/" + source.Name
+		if seen[id] {
+			return fmt.Errorf("datasource [%s] declared more than once", id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+func (p *Plugin) Init(ctx context.Context) error {
+	for _, source := range p.sources {
+		err := p.initSource(ctx, source)
+		if err != nil {
+			return fmt.Errorf("datasource [%s] %s: %w", source.Kind, source.Name, err)
+		}
+	}
+	return nil
+}
+
+func (p *Plugin) initSource(ctx context.Context, source Source) error {
+	initializer, err := getKind(source.Kind)
+	if err != nil {
+		return err
+	}
+	item, err := config.Get(ctx, source.Key)
+	if err != nil {
+		return err
+	}
+	if item.GetValue() == "" {
+		return fmt.Errorf("config key [%s] is empty", source.Key)
+	}
+	codec := config.CodecByKey(source.Key)
+	replaced, err := initializer.Init(ctx, source.Name, []byte(item.GetValue()), codec)
+	if err != nil {
+		return err
+	}
+	p.closeLater(ctx, source, replaced)
+	//rebuilds of one source are serialized by the watcher
+	var mu sync.Mutex
+	return config.Watch(ctx, item, func(item *config.Item) {
+		mu.Lock()
+		defer mu.Unlock()
+		fields := []zap.Field{logger.WithField("kind", source.Kind), logger.WithField("name", source.Name), logger.WithField("key", source.Key)}
+		if item.IsDeleted() || item.GetValue() == "" {
+			logger.Warning(ctx, "datasource config removed,keep current client", nil, fields...)
+			return
+		}
+		replaced, err := initializer.Init(ctx, source.Name, []byte(item.GetValue()), codec)
+		if err != nil {
+			logger.Error(ctx, "datasource rebuild failed,keep current client", err, fields...)
+			return
+		}
+		logger.Info(ctx, "datasource rebuilt", fields...)
+		p.closeLater(ctx, source, replaced)
+	})
+}
+
+// closeLater close replaced client after close delay
+func (p *Plugin) closeLater(ctx context.Context, source Source, replaced io.Closer) {
+	if replaced == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var timer *time.Timer
+	timer = time.AfterFunc(p.conf.CloseDelay, func() {
+		p.mu.Lock()
+		client, ok := p.pending[timer]
+		delete(p.pending, timer)
+		p.mu.Unlock()
+		if ok {
+			client.close(ctx)
+		}
+	})
+	p.pending[timer] = &replacedClient{source: source, closer: replaced}
+}
+
+func (c *replacedClient) close(ctx context.Context) {
+	if err := c.closer.Close(); err != nil {
+		logger.Error(ctx, "datasource close replaced client", err, logger.WithField("kind", c.source.Kind), logger.WithField("name", c.source.Name))
+	}
+}
+
+func (p *Plugin) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+// Stop close replaced clients without waiting for close delay
+func (p *Plugin) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	pending := p.pending
+	p.pending = map[*time.Timer]*replacedClient{}
+	p.mu.Unlock()
+	//timers fired meanwhile skip clients not pending any more
+	for timer, client := range pending {
+		timer.Stop()
+		client.close(ctx)
+	}
+	return nil
+}
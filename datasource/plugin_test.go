@@ -0,0 +1,102 @@
+package datasource
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/no-mole/neptune/config"
+)
+
+type fakeClient struct {
+	addr   string
+	closed chan struct{}
+}
+
+func (f *fakeClient) Close() error {
+	close(f.closed)
+	return nil
+}
+
+func TestPlugin(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var mu sync.Mutex
+	clients := map[string]*fakeClient{}
+	RegisterKind("fake", InitializerFunc(func(_ context.Context, name string, value []byte, codec config.Codec) (io.Closer, error) {
+		conf := &struct {
+			Addr string `json:"addr"`
+		}{}
+		if err := codec.Unmarshal(value, conf); err != nil {
+			return nil, err
+		}
+		if conf.Addr == "" {
+			return nil, errors.New("addr is required")
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		old, exist := clients[name]
+		clients[name] = &fakeClient{addr: conf.Addr, closed: make(chan struct{})}
+		if !exist {
+			return nil, nil
+		}
+		return old, nil
+	}))
+	current := func() *fakeClient {
+		mu.Lock()
+		defer mu.Unlock()
+		return clients["main"]
+	}
+
+	err := config.InitDefaultClient(ctx, &config.Config{Type: "memory"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = config.Set(ctx, "fake.json", `{"addr":"a"}`)
+	p := NewPlugin(Source{Name: "main", Key: "fake.json", Kind: "fake"})
+	p.conf.CloseDelay = 50 * time.Millisecond
+	if err = p.ValidateConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+	first := current()
+	if first.addr != "a" {
+		t.Fatalf("unexpected addr %s", first.addr)
+	}
+
+	_ = config.Set(ctx, "fake.json", `{"addr":"b"}`)
+	if current().addr != "b" {
+		t.Fatalf("client not rebuilt,addr %s", current().addr)
+	}
+	select {
+	case <-first.closed:
+		t.Fatal("replaced client closed before close delay")
+	default:
+	}
+	select {
+	case <-first.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("replaced client not closed")
+	}
+
+	//invalid config keeps the current client
+	second := current()
+	_ = config.Set(ctx, "fake.json", `{}`)
+	if current() != second {
+		t.Fatal("client replaced by invalid config")
+	}
+	_ = config.Set(ctx, "fake.json", `{"addr":"c"}`)
+	if err = p.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-second.closed:
+	default:
+		t.Fatal("pending client not closed on stop")
+	}
+}
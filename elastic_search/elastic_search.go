@@ -32,9 +32,13 @@ func InitElasticSearch(esName string, confStr string, opts ...elastic.ClientOpti
 	esConf := &Config{}
 	err := json.Unmarshal([]byte(confStr), esConf)
 	if err != nil {
-		panic(err)
+		return err
 	}
+	return InitElasticSearchWithConfig(esName, esConf, opts...)
+}
 
+// InitElasticSearchWithConfig create client and store it replacing the previous one of esName
+func InitElasticSearchWithConfig(esName string, esConf *Config, opts ...elastic.ClientOptionFunc) error {
 	httpClient := &http.Client{
 		Transport: opentelemetry.NewTransport(),
 	}
@@ -54,7 +58,7 @@ func InitElasticSearch(esName string, confStr string, opts ...elastic.ClientOpti
 	}
 	client, err := elastic.NewClient(options...)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	Client.StoreClient(esName, client)
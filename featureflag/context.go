@@ -0,0 +1,41 @@
+package featureflag
+
+import "context"
+
+const (
+	AttributeUserId = "user_id"
+	AttributeTenant = "tenant"
+	//AttributeMode app mode such as application.AppModeProd,set by the engine
+	AttributeMode = "mode"
+)
+
+// Attributes targeting attributes of the current request
+type Attributes map[string]string
+
+type attributesKey struct{}
+
+// WithAttributes merge attrs into attributes of ctx
+func WithAttributes(ctx context.Context, attrs Attributes) context.Context {
+	merged := Attributes{}
+	for k, v := range AttributesFromContext(ctx) {
+		merged[k] = v
+	}
+	for k, v := range attrs {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, attributesKey{}, merged)
+}
+
+func WithUserId(ctx context.Context, userId string) context.Context {
+	return WithAttributes(ctx, Attributes{AttributeUserId: userId})
+}
+
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return WithAttributes(ctx, Attributes{AttributeTenant: tenant})
+}
+
+// AttributesFromContext attributes of ctx,callers must not modify it
+func AttributesFromContext(ctx context.Context) Attributes {
+	attrs, _ := ctx.Value(attributesKey{}).(Attributes)
+	return attrs
+}
@@ -0,0 +1,154 @@
+package featureflag
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/no-mole/neptune/config"
+	"github.com/no-mole/neptune/logger"
+)
+
+// DefaultRetryInterval interval of retrying to bind flags while the config center is unreachable
+var DefaultRetryInterval = 30 * time.Second
+
+type options struct {
+	client        config.Client
+	mode          string
+	defaults      map[string]bool
+	retryInterval time.Duration
+}
+
+type Option func(o *options)
+
+// WithClient read flags from client instead of the default config client
+func WithClient(client config.Client) Option {
+	return func(o *options) {
+		o.client = client
+	}
+}
+
+// WithMode app mode exposed to rules as the mode attribute
+func WithMode(mode string) Option {
+	return func(o *options) {
+		o.mode = mode
+	}
+}
+
+// WithDefaults values of flags used until flags are loaded,and for flags not defined in config
+func WithDefaults(defaults map[string]bool) Option {
+	return func(o *options) {
+		o.defaults = defaults
+	}
+}
+
+func WithRetryInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.retryInterval = interval
+	}
+}
+
+// Engine evaluate flags locally,flags are watched from the config key
+type Engine struct {
+	key  string
+	opts *options
+
+	mu      sync.RWMutex
+	binding *config.Binding[Flags]
+}
+
+// New bind flags of key and watch it until ctx is done.
+// When the config center is unreachable defaults are served and binding is retried in background
+func New(ctx context.Context, key string, opts ...Option) *Engine {
+	o := &options{retryInterval: DefaultRetryInterval}
+	for _, opt := range opts {
+		opt(o)
+	}
+	e := &Engine{key: key, opts: o}
+	if err := e.bind(ctx); err != nil {
+		logger.Error(ctx, "feature flags not loaded,serving defaults", err, logger.WithField("key", key))
+		go e.retry(ctx)
+	}
+	return e
+}
+
+func (e *Engine) bind(ctx context.Context) error {
+	var bindOpts []config.BindOption
+	if e.opts.client != nil {
+		bindOpts = append(bindOpts, config.WithBindClient(e.opts.client))
+	}
+	binding, err := config.Bind[Flags](ctx, e.key, bindOpts...)
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	e.binding = binding
+	e.mu.Unlock()
+	return nil
+}
+
+func (e *Engine) retry(ctx context.Context) {
+	ticker := time.NewTicker(e.opts.retryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		err := e.bind(ctx)
+		if err == nil {
+			logger.Info(ctx, "feature flags loaded", logger.WithField("key", e.key))
+			return
+		}
+		logger.Error(ctx, "feature flags retry", err, logger.WithField("key", e.key))
+	}
+}
+
+// Flags current flags,nil until loaded.callers must not modify it
+func (e *Engine) Flags() Flags {
+	e.mu.RLock()
+	binding := e.binding
+	e.mu.RUnlock()
+	if binding == nil {
+		return nil
+	}
+	return *binding.Load()
+}
+
+// Enabled evaluate flag name with attributes of ctx,
+// def is returned when the flag is neither defined in config nor in WithDefaults
+func (e *Engine) Enabled(ctx context.Context, name string, def bool) bool {
+	flag, ok := e.Flags()[name]
+	if !ok {
+		if value, ok := e.opts.defaults[name]; ok {
+			return value
+		}
+		return def
+	}
+	attrs := AttributesFromContext(ctx)
+	if _, ok = attrs[AttributeMode]; !ok && e.opts.mode != "" {
+		withMode := make(Attributes, len(attrs)+1)
+		for k, v := range attrs {
+			withMode[k] = v
+		}
+		withMode[AttributeMode] = e.opts.mode
+		attrs = withMode
+	}
+	return flag.Evaluate(name, attrs)
+}
+
+var defaultEngine *Engine
+
+// SetDefault set engine used by package level Enabled
+func SetDefault(e *Engine) {
+	defaultEngine = e
+}
+
+// Enabled evaluate flag by the default engine,def is returned when no default engine
+func Enabled(ctx context.Context, name string, def bool) bool {
+	if defaultEngine == nil {
+		return def
+	}
+	return defaultEngine.Enabled(ctx, name, def)
+}
@@ -0,0 +1,58 @@
+package featureflag
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/no-mole/neptune/config"
+)
+
+func TestEngine(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := config.NewMemoryConfigClient(map[string]string{"flags.json": `{
+		"checkout": {"enabled": true, "rules": [
+			{"attribute": "tenant", "operator": "in", "values": ["vip"]},
+			{"attribute": "mode", "operator": "in", "values": ["prod"], "serve": false}
+		], "rollout": 50},
+		"off": {"enabled": false}
+	}`})
+	e := New(ctx, "flags.json", WithClient(client), WithMode("prod"), WithDefaults(map[string]bool{"missing": true}))
+
+	if !e.Enabled(WithTenant(ctx, "vip"), "checkout", false) {
+		t.Error("tenant rule should enable checkout")
+	}
+	if e.Enabled(WithTenant(ctx, "other"), "checkout", true) {
+		t.Error("mode rule should disable checkout in prod")
+	}
+	if e.Enabled(ctx, "off", true) {
+		t.Error("disabled flag should be off")
+	}
+	if !e.Enabled(ctx, "missing", false) || !e.Enabled(ctx, "undefined", true) {
+		t.Error("defaults should be used for flags not defined")
+	}
+
+	//rollout is stable per user and close to the percentage
+	_ = client.Set(ctx, "flags.json", `{"checkout": {"enabled": true, "rollout": 30}}`)
+	on := 0
+	for i := 0; i < 1000; i++ {
+		userCtx := WithUserId(ctx, fmt.Sprint(i))
+		enabled := e.Enabled(userCtx, "checkout", false)
+		if enabled != e.Enabled(userCtx, "checkout", false) {
+			t.Fatal("rollout should be stable")
+		}
+		if enabled {
+			on++
+		}
+	}
+	if on < 250 || on > 350 {
+		t.Errorf("rollout 30%% enabled %d of 1000 users", on)
+	}
+
+	//invalid update keeps last flags
+	_ = client.Set(ctx, "flags.json", `{"checkout": {"enabled": true, "rollout": 300}}`)
+	if e.Flags()["checkout"].Rollout == nil || *e.Flags()["checkout"].Rollout != 30 {
+		t.Error("invalid flags should be rejected")
+	}
+}
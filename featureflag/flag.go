@@ -0,0 +1,150 @@
+package featureflag
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+const (
+	OperatorIn     = "in"
+	OperatorNotIn  = "not_in"
+	OperatorPrefix = "prefix"
+	OperatorExists = "exists"
+)
+
+// Flags flags by name,decoded from the config value such as
+//
+//	{
+//	  "new-checkout": {
+//	    "enabled": true,
+//	    "rules": [
+//	      {"attribute": "tenant", "operator": "in", "values": ["t1"]},
+//	      {"attribute": "mode", "operator": "in", "values": ["prod"], "rollout": 10}
+//	    ],
+//	    "rollout": 0
+//	  }
+//	}
+type Flags map[string]*Flag
+
+// Flag boolean flag,disabled flags are off for everyone.
+// Rules are matched in order and the first matched rule decides,
+// otherwise Rollout decides when set,otherwise the flag is on
+type Flag struct {
+	Enabled bool    `json:"enabled" yaml:"enabled"`
+	Rules   []*Rule `json:"rules,omitempty" yaml:"rules,omitempty"`
+	//Rollout percentage in [0,100] of RolloutBy attribute values the flag is on for
+	Rollout *float64 `json:"rollout,omitempty" yaml:"rollout,omitempty"`
+	//RolloutBy attribute bucketed by rollout,default is user_id
+	RolloutBy string `json:"rollout_by,omitempty" yaml:"rollout_by,omitempty"`
+}
+
+// Rule targeting rule,Serve is the result when matched and Rollout is not set,default is true
+type Rule struct {
+	Attribute string   `json:"attribute" yaml:"attribute"`
+	Operator  string   `json:"operator" yaml:"operator"`
+	Values    []string `json:"values,omitempty" yaml:"values,omitempty"`
+	Serve     *bool    `json:"serve,omitempty" yaml:"serve,omitempty"`
+	Rollout   *float64 `json:"rollout,omitempty" yaml:"rollout,omitempty"`
+}
+
+func (f Flags) Validate() error {
+	for name, flag := range f {
+		if flag == nil {
+			return fmt.Errorf("flag [%s] is null", name)
+		}
+		if err := validateRollout(flag.Rollout); err != nil {
+			return fmt.Errorf("flag [%s]: %w", name, err)
+		}
+		for i, rule := range flag.Rules {
+			switch rule.Operator {
+			case OperatorIn, OperatorNotIn, OperatorPrefix, OperatorExists:
+			default:
+				return fmt.Errorf("flag [%s] rule %d: unknown operator %q", name, i, rule.Operator)
+			}
+			if rule.Attribute == "" {
+				return fmt.Errorf("flag [%s] rule %d: attribute is empty", name, i)
+			}
+			if err := validateRollout(rule.Rollout); err != nil {
+				return fmt.Errorf("flag [%s] rule %d: %w", name, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateRollout(rollout *float64) error {
+	if rollout != nil && (*rollout < 0 || *rollout > 100) {
+		return fmt.Errorf("rollout %v out of range [0,100]", *rollout)
+	}
+	return nil
+}
+
+// Evaluate evaluate flag named name with attributes
+func (f *Flag) Evaluate(name string, attrs Attributes) bool {
+	if !f.Enabled {
+		return false
+	}
+	for _, rule := range f.Rules {
+		if !rule.match(attrs) {
+			continue
+		}
+		if rule.Rollout != nil {
+			return inRollout(name, attrs[f.rolloutBy()], *rule.Rollout)
+		}
+		return rule.Serve == nil || *rule.Serve
+	}
+	if f.Rollout != nil {
+		return inRollout(name, attrs[f.rolloutBy()], *f.Rollout)
+	}
+	return true
+}
+
+func (f *Flag) rolloutBy() string {
+	if f.RolloutBy == "" {
+		return AttributeUserId
+	}
+	return f.RolloutBy
+}
+
+func (r *Rule) match(attrs Attributes) bool {
+	value, ok := attrs[r.Attribute]
+	switch r.Operator {
+	case OperatorExists:
+		return ok && value != ""
+	case OperatorNotIn:
+		return !contains(r.Values, value)
+	case OperatorPrefix:
+		for _, prefix := range r.Values {
+			if ok && strings.HasPrefix(value, prefix) {
+				return true
+			}
+		}
+		return false
+	default:
+		return ok && contains(r.Values, value)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// inRollout stable bucket of value in [0,100),the same value stays in as the percentage grows.
+// Empty value is never in a partial rollout
+func inRollout(name, value string, percentage float64) bool {
+	if percentage >= 100 {
+		return true
+	}
+	if value == "" || percentage <= 0 {
+		return false
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name + ":" + value))
+	return float64(h.Sum32()%10000)/100 < percentage
+}
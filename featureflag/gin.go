@@ -0,0 +1,51 @@
+package featureflag
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	HeaderUserId = "X-User-Id"
+	HeaderTenant = "X-Tenant"
+)
+
+// HeaderAttributes attributes from X-User-Id and X-Tenant headers
+func HeaderAttributes(ctx *gin.Context) Attributes {
+	attrs := Attributes{}
+	if userId := ctx.GetHeader(HeaderUserId); userId != "" {
+		attrs[AttributeUserId] = userId
+	}
+	if tenant := ctx.GetHeader(HeaderTenant); tenant != "" {
+		attrs[AttributeTenant] = tenant
+	}
+	return attrs
+}
+
+// GinAttributes put attributes extracted from request into request context,extract is HeaderAttributes when nil
+func GinAttributes(extract func(ctx *gin.Context) Attributes) gin.HandlerFunc {
+	if extract == nil {
+		extract = HeaderAttributes
+	}
+	return func(ctx *gin.Context) {
+		ctx.Request = ctx.Request.WithContext(WithAttributes(ctx.Request.Context(), extract(ctx)))
+		ctx.Next()
+	}
+}
+
+// GinEnabled evaluate flag by the default engine with attributes of request
+func GinEnabled(ctx *gin.Context, name string, def bool) bool {
+	return Enabled(ctx.Request.Context(), name, def)
+}
+
+// GinRequire respond 404 when flag is off,for routes behind a flag
+func GinRequire(name string, def bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !GinEnabled(ctx, name, def) {
+			ctx.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,69 @@
+package featureflag
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// MetadataAttributes attributes from x-user-id and x-tenant metadata of incoming request
+func MetadataAttributes(ctx context.Context) Attributes {
+	attrs := Attributes{}
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(HeaderUserId); len(values) > 0 && values[0] != "" {
+		attrs[AttributeUserId] = values[0]
+	}
+	if values := md.Get(HeaderTenant); len(values) > 0 && values[0] != "" {
+		attrs[AttributeTenant] = values[0]
+	}
+	return attrs
+}
+
+// GrpcUnaryServerInterceptor put attributes extracted from request into context,extract is MetadataAttributes when nil
+func GrpcUnaryServerInterceptor(extract func(ctx context.Context) Attributes) grpc.ServerOption {
+	if extract == nil {
+		extract = MetadataAttributes
+	}
+	return grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(WithAttributes(ctx, extract(ctx)), req)
+	})
+}
+
+func GrpcStreamServerInterceptor(extract func(ctx context.Context) Attributes) grpc.ServerOption {
+	if extract == nil {
+		extract = MetadataAttributes
+	}
+	return grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &attributesServerStream{ServerStream: ss, ctx: WithAttributes(ss.Context(), extract(ss.Context()))})
+	})
+}
+
+// GrpcUnaryClientInterceptor propagate user id and tenant of ctx to outgoing metadata
+func GrpcUnaryClientInterceptor() grpc.DialOption {
+	return grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		attrs := AttributesFromContext(ctx)
+		if userId := attrs[AttributeUserId]; userId != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, strings.ToLower(HeaderUserId), userId)
+		}
+		if tenant := attrs[AttributeTenant]; tenant != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, strings.ToLower(HeaderTenant), tenant)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	})
+}
+
+// GrpcEnabled evaluate flag by the default engine with attributes of ctx
+func GrpcEnabled(ctx context.Context, name string, def bool) bool {
+	return Enabled(ctx, name, def)
+}
+
+type attributesServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *attributesServerStream) Context() context.Context {
+	return s.ctx
+}
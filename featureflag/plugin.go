@@ -0,0 +1,49 @@
+package featureflag
+
+import (
+	"context"
+	"time"
+
+	"github.com/no-mole/neptune/application"
+)
+
+// NewPlugin feature flag plugin bind flags from config center and set the default engine,
+// the app mode is exposed to rules as the mode attribute
+func NewPlugin(app *application.App) *Plugin {
+	conf := &Conf{}
+	return &Plugin{
+		ConfigPlugin: application.NewConfigPlugin("featureflag", &application.PluginConfigOptions{
+			ConfigName: "app.yaml",
+			ConfigType: "yaml",
+		}, conf),
+		app:  app,
+		conf: conf,
+	}
+}
+
+type Plugin struct {
+	*application.ConfigPlugin[Conf] `yaml:"-" json:"-"`
+
+	app  *application.App
+	conf *Conf
+}
+
+type Conf struct {
+	Key           string        `json:"featureflag-key" yaml:"featureflag-key" flag:"featureflag-key" default:"feature_flags.json" usage:"config center key of flags,decoded by key extension,default is feature_flags.json" validate:"required"`
+	RetryInterval time.Duration `json:"featureflag-retry-interval" yaml:"featureflag-retry-interval" flag:"featureflag-retry-interval" default:"30s" usage:"interval of retrying to load flags while config center is unreachable,default is 30s" validate:"gt=0"`
+}
+
+// Dependencies flags are read by the default config client
+func (p *Plugin) Dependencies() []string {
+	return []string{"config-center"}
+}
+
+func (p *Plugin) Init(ctx context.Context) error {
+	SetDefault(New(ctx, p.conf.Key, WithMode(p.app.Mode), WithRetryInterval(p.conf.RetryInterval)))
+	return nil
+}
+
+func (p *Plugin) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
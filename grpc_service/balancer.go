@@ -0,0 +1,174 @@
+package grpc_service
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	_ "google.golang.org/grpc/health" //client side health checking of healthCheckConfig
+	"google.golang.org/grpc/resolver"
+)
+
+const WeightedBalancerName = "neptune_weighted"
+
+// WeightedServiceConfig service config selecting the weighted balancer,subConns reported NOT_SERVING
+// by grpc.health.v1 service "" are excluded
+const WeightedServiceConfig = `{"loadBalancingConfig":[{"neptune_weighted":{}}],"healthCheckConfig":{"serviceName":""}}`
+
+var (
+	// SlowStartWindow traffic of instances started within the window ramps up linearly,0 disables slow start
+	SlowStartWindow = 30 * time.Second
+	// SlowStartMinRatio ratio of weight served by an instance just started
+	SlowStartMinRatio = 0.1
+	// ZoneAware prefer ready instances in the zone of LocalInstance,fall back to all zones when none ready
+	ZoneAware = true
+)
+
+func init() {
+	balancer.Register(&weightedBuilder{})
+}
+
+// WithWeightedBalancer dial option to balance by instance weights,zone and slow start
+func WithWeightedBalancer() grpc.DialOption {
+	return grpc.WithDefaultServiceConfig(WeightedServiceConfig)
+}
+
+type weightedBuilder struct{}
+
+func (w *weightedBuilder) Name() string {
+	return WeightedBalancerName
+}
+
+func (w *weightedBuilder) Build(cc balancer.ClientConn, opts balancer.BuildOptions) balancer.Balancer {
+	b := &weightedBalancer{instances: map[string]*Instance{}}
+	b.Balancer = base.NewBalancerBuilder(WeightedBalancerName, b, base.Config{HealthCheck: true}).Build(cc, opts)
+	return b
+}
+
+// weightedBalancer base balancer keeps the addresses of subConns first resolved,
+// so the latest instances are tracked here to follow weight changes
+type weightedBalancer struct {
+	balancer.Balancer
+
+	mu        sync.RWMutex
+	instances map[string]*Instance
+}
+
+func (b *weightedBalancer) UpdateClientConnState(s balancer.ClientConnState) error {
+	instances := make(map[string]*Instance, len(s.ResolverState.Addresses))
+	for _, addr := range s.ResolverState.Addresses {
+		instances[addr.Addr] = InstanceFromAddress(addr)
+	}
+	b.mu.Lock()
+	b.instances = instances
+	b.mu.Unlock()
+	return b.Balancer.UpdateClientConnState(s)
+}
+
+func (b *weightedBalancer) instance(addr resolver.Address) *Instance {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if instance, ok := b.instances[addr.Addr]; ok && instance != nil {
+		return instance
+	}
+	return InstanceFromAddress(addr)
+}
+
+func (b *weightedBalancer) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	var all, local []*weightedSubConn
+	zone := LocalInstance().Zone
+	for sc, sci := range info.ReadySCs {
+		instance := b.instance(sci.Address)
+		wsc := &weightedSubConn{sc: sc, weight: float64(instance.GetWeight())}
+		if instance != nil {
+			wsc.start = instance.StartTime
+		}
+		all = append(all, wsc)
+		if ZoneAware && zone != "" && instance != nil && instance.Zone == zone {
+			local = append(local, wsc)
+		}
+	}
+	if len(local) > 0 {
+		all = local
+	}
+	return newWeightedPicker(all, time.Now())
+}
+
+type weightedSubConn struct {
+	sc     balancer.SubConn
+	weight float64
+	start  time.Time
+}
+
+// effectiveWeight weight ramped up from SlowStartMinRatio to 1 within SlowStartWindow after start
+func (w *weightedSubConn) effectiveWeight(now time.Time) float64 {
+	if SlowStartWindow <= 0 || w.start.IsZero() {
+		return w.weight
+	}
+	elapsed := now.Sub(w.start)
+	if elapsed >= SlowStartWindow {
+		return w.weight
+	}
+	ratio := float64(elapsed) / float64(SlowStartWindow)
+	if ratio < SlowStartMinRatio {
+		ratio = SlowStartMinRatio
+	}
+	return w.weight * ratio
+}
+
+func newWeightedPicker(scs []*weightedSubConn, now time.Time) *weightedPicker {
+	p := &weightedPicker{scs: scs}
+	for _, sc := range scs {
+		if end := sc.start.Add(SlowStartWindow); !sc.start.IsZero() && end.After(p.warmUntil) {
+			p.warmUntil = end
+		}
+	}
+	p.cumulative = p.weights(now)
+	return p
+}
+
+// weightedPicker weighted random pick,weights are recomputed on each pick while any instance is warming up
+type weightedPicker struct {
+	scs        []*weightedSubConn
+	cumulative []float64
+	warmUntil  time.Time
+}
+
+func (p *weightedPicker) weights(now time.Time) []float64 {
+	cumulative := make([]float64, len(p.scs))
+	var total float64
+	for i, sc := range p.scs {
+		total += sc.effectiveWeight(now)
+		cumulative[i] = total
+	}
+	return cumulative
+}
+
+func (p *weightedPicker) Pick(_ balancer.PickInfo) (balancer.PickResult, error) {
+	cumulative := p.cumulative
+	if now := time.Now(); now.Before(p.warmUntil) {
+		cumulative = p.weights(now)
+	}
+	return balancer.PickResult{SubConn: p.scs[pickIndex(cumulative, rand.Float64())].sc}, nil
+}
+
+// pickIndex index of the first cumulative weight above r*total
+func pickIndex(cumulative []float64, r float64) int {
+	target := r * cumulative[len(cumulative)-1]
+	lo, hi := 0, len(cumulative)-1
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if cumulative[mid] > target {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo
+}
@@ -0,0 +1,85 @@
+package grpc_service
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+)
+
+type fakeSubConn struct {
+	balancer.SubConn
+	addr string
+}
+
+func buildPicker(t *testing.T, instances map[string]*Instance) balancer.Picker {
+	t.Helper()
+	b := &weightedBalancer{instances: map[string]*Instance{}}
+	info := base.PickerBuildInfo{ReadySCs: map[balancer.SubConn]base.SubConnInfo{}}
+	for addr, instance := range instances {
+		address := WithInstance(resolver.Address{Addr: addr}, instance)
+		info.ReadySCs[&fakeSubConn{addr: addr}] = base.SubConnInfo{Address: address}
+	}
+	return b.Build(info)
+}
+
+func pickCounts(t *testing.T, p balancer.Picker, n int) map[string]int {
+	t.Helper()
+	counts := map[string]int{}
+	for i := 0; i < n; i++ {
+		result, err := p.Pick(balancer.PickInfo{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[result.SubConn.(*fakeSubConn).addr]++
+	}
+	return counts
+}
+
+func TestWeightedPicker(t *testing.T) {
+	defer SetLocalInstance(LocalInstance())
+	old := time.Now().Add(-time.Hour)
+
+	SetLocalInstance(&Instance{})
+	counts := pickCounts(t, buildPicker(t, map[string]*Instance{
+		"a:1": {Weight: 30, StartTime: old},
+		"b:1": {Weight: 10, StartTime: old},
+	}), 8000)
+	if ratio := float64(counts["a:1"]) / float64(counts["b:1"]); ratio < 2.5 || ratio > 3.5 {
+		t.Fatalf("unexpected weighted ratio %v", counts)
+	}
+
+	SetLocalInstance(&Instance{Zone: "z1"})
+	counts = pickCounts(t, buildPicker(t, map[string]*Instance{
+		"a:1": {Weight: 10, Zone: "z1", StartTime: old},
+		"b:1": {Weight: 100, Zone: "z2", StartTime: old},
+	}), 100)
+	if counts["a:1"] != 100 {
+		t.Fatalf("same zone instance not preferred %v", counts)
+	}
+
+	SetLocalInstance(&Instance{})
+	counts = pickCounts(t, buildPicker(t, map[string]*Instance{
+		"a:1": {Weight: 10, StartTime: old},
+		"b:1": {Weight: 10, StartTime: time.Now()},
+	}), 4000)
+	if counts["b:1"]*3 > counts["a:1"] {
+		t.Fatalf("slow start instance got too much traffic %v", counts)
+	}
+}
+
+func TestDecodeInstance(t *testing.T) {
+	instance := &Instance{Hostname: "h", Weight: 5, Zone: "z", Tags: map[string]string{"k": "v"}, StartTime: time.Now()}
+	if decoded := DecodeInstance([]byte(instance.Encode())); !decoded.Equal(instance) {
+		t.Fatalf("decoded %+v", decoded)
+	}
+	legacy := DecodeInstance([]byte("hostname"))
+	if legacy.Hostname != "hostname" || legacy.GetWeight() != DefaultWeight {
+		t.Fatalf("legacy value decoded %+v", legacy)
+	}
+	if decoded := InstanceFromNacos(5, instance.metadata(nacosMetadataTagPrefix)); !decoded.Equal(instance) {
+		t.Fatalf("nacos decoded %+v", decoded)
+	}
+}
@@ -0,0 +1,296 @@
+package grpc_service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/no-mole/neptune/health"
+	"github.com/no-mole/neptune/logger"
+	"google.golang.org/grpc/resolver"
+)
+
+const ResolverConsulScheme = "neptune-consul"
+
+// consulMetaUniqueKey service meta holding the unique key,names from templates may collide
+const consulMetaUniqueKey = "neptune_unique_key"
+
+var (
+	// DefaultConsulTTL ttl of health checks when ttl setting is not set,checks are updated every third of it
+	DefaultConsulTTL = 10 * time.Second
+	// DefaultConsulDeregisterAfter services critical for this long are removed by consul
+	DefaultConsulDeregisterAfter = time.Minute
+	// ConsulRetryInterval interval before retrying a failed blocking query
+	ConsulRetryInterval = time.Second
+)
+
+// NewConsulRegister 创建一个consul注册器,服务名由name模板生成,健康检查为ttl检查,状态取自health.Readiness
+func NewConsulRegister(ctx context.Context, client *consulapi.Client, name *NameTemplate, ttl, deregisterAfter time.Duration) RegisterInterface {
+	r := &ConsulRegister{
+		ctx:             ctx,
+		client:          client,
+		name:            name,
+		ttl:             ttl,
+		deregisterAfter: deregisterAfter,
+		services:        &RegisterServices{},
+		close:           make(chan struct{}),
+	}
+	go r.heartbeat()
+	return r
+}
+
+type ConsulRegister struct {
+	ctx context.Context
+
+	client          *consulapi.Client
+	name            *NameTemplate
+	ttl             time.Duration
+	deregisterAfter time.Duration
+
+	services *RegisterServices
+
+	close     chan struct{}
+	closeOnce sync.Once
+}
+
+func (c *ConsulRegister) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.close)
+	})
+	return nil
+}
+
+func (c *ConsulRegister) Register(ctx context.Context, service Metadata, endpoint string) error {
+	err := c.register(ctx, service, endpoint)
+	if err != nil {
+		return err
+	}
+	c.services.Put(service, endpoint)
+	return nil
+}
+
+func (c *ConsulRegister) Unregister(ctx context.Context, service Metadata, endpoint string) error {
+	id, err := c.serviceId(service, endpoint)
+	if err != nil {
+		return err
+	}
+	err = c.client.Agent().ServiceDeregisterOpts(id, (&consulapi.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	c.services.Del(service, endpoint)
+	return nil
+}
+
+// Ping check connectivity by asking the raft leader
+func (c *ConsulRegister) Ping(_ context.Context) error {
+	_, err := c.client.Status().Leader()
+	return err
+}
+
+// DiscoveryKey consul service id of the registered endpoint
+func (c *ConsulRegister) DiscoveryKey(service Metadata, endpoint string) string {
+	id, _ := c.serviceId(service, endpoint)
+	return id
+}
+
+func (c *ConsulRegister) register(ctx context.Context, service Metadata, endpoint string) error {
+	name, err := c.name.Name(service.UniqueKey())
+	if err != nil {
+		return err
+	}
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return err
+	}
+	portInt, err := strconv.Atoi(port)
+	if err != nil {
+		return err
+	}
+	instance := LocalInstance()
+	meta := instance.metadata(consulMetadataTagPrefix)
+	meta[consulMetaUniqueKey] = service.UniqueKey()
+	id := consulServiceId(name, endpoint)
+	return c.client.Agent().ServiceRegisterOpts(&consulapi.AgentServiceRegistration{
+		ID:      id,
+		Name:    name,
+		Address: host,
+		Port:    portInt,
+		Meta:    meta,
+		Weights: &consulapi.AgentWeights{Passing: instance.GetWeight(), Warning: 1},
+		Check: &consulapi.AgentServiceCheck{
+			CheckID:                        consulCheckId(id),
+			TTL:                            c.ttl.String(),
+			Status:                         c.status(ctx),
+			DeregisterCriticalServiceAfter: c.deregisterAfter.String(),
+		},
+	}, consulapi.ServiceRegisterOpts{ReplaceExistingChecks: true}.WithContext(ctx))
+}
+
+// heartbeat update ttl checks,services lost by the agent such as after agent restart are registered again
+func (c *ConsulRegister) heartbeat() {
+	ticker := time.NewTicker(c.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.close:
+			return
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		status := c.status(c.ctx)
+		_ = c.services.Range(func(service Metadata, endpoint string) error {
+			id, err := c.serviceId(service, endpoint)
+			if err != nil {
+				return nil
+			}
+			err = c.client.Agent().UpdateTTLOpts(consulCheckId(id), "", status, (&consulapi.QueryOptions{}).WithContext(c.ctx))
+			if err == nil {
+				return nil
+			}
+			logger.Warning(c.ctx, "consul register update ttl,register again", err, logger.WithField("serviceId", id))
+			if err = c.register(c.ctx, service, endpoint); err != nil {
+				logger.Error(c.ctx, "consul register", err, logger.WithField("serviceId", id))
+			}
+			return nil
+		})
+	}
+}
+
+// status readiness of this process as consul check status
+func (c *ConsulRegister) status(ctx context.Context) string {
+	if health.Readiness(ctx).Status == health.StatusUp {
+		return consulapi.HealthPassing
+	}
+	return consulapi.HealthCritical
+}
+
+func (c *ConsulRegister) serviceId(service Metadata, endpoint string) (string, error) {
+	name, err := c.name.Name(service.UniqueKey())
+	if err != nil {
+		return "", err
+	}
+	return consulServiceId(name, endpoint), nil
+}
+
+func consulServiceId(name, endpoint string) string {
+	return name + "-" + strings.ReplaceAll(endpoint, ":", "-")
+}
+
+func consulCheckId(serviceId string) string {
+	return "service:" + serviceId
+}
+
+// RegisterConsulResolverBuilder 创建一个consul解析器构建器，解析器schema为 ResolverConsulScheme
+func RegisterConsulResolverBuilder(ctx context.Context, client *consulapi.Client, name *NameTemplate) resolver.Builder {
+	builder := &ConsulResolverBuilder{ctx: ctx, client: client, name: name}
+	resolver.Register(builder)
+	return builder
+}
+
+type ConsulResolverBuilder struct {
+	ctx    context.Context
+	client *consulapi.Client
+	name   *NameTemplate
+}
+
+func (c *ConsulResolverBuilder) Scheme() string {
+	return ResolverConsulScheme
+}
+
+func (c *ConsulResolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	// "neptune-consul:///zeus/zeus.proto/zeus.ZeusService/v1" resolves passing instances of zeus-zeusservice-v1
+	uniqueKey := "/" + strings.TrimPrefix(target.Endpoint(), "/")
+	name, err := c.name.Name(uniqueKey)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(c.ctx)
+	r := &consulResolver{
+		ctx:       ctx,
+		cancel:    cancel,
+		client:    c.client,
+		service:   name,
+		uniqueKey: uniqueKey,
+		cc:        cc,
+	}
+	r.wg.Add(1)
+	go r.watch()
+	return r, nil
+}
+
+type consulResolver struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	client    *consulapi.Client
+	service   string
+	uniqueKey string
+	cc        resolver.ClientConn
+	wg        sync.WaitGroup
+}
+
+// ResolveNow instances are watched by blocking queries
+func (c *consulResolver) ResolveNow(_ resolver.ResolveNowOptions) {}
+
+func (c *consulResolver) Close() {
+	c.cancel()
+	c.wg.Wait()
+}
+
+// watch blocking queries on passing instances of service until closed
+func (c *consulResolver) watch() {
+	defer c.wg.Done()
+	var index uint64
+	for {
+		entries, meta, err := c.client.Health().Service(c.service, "", true,
+			(&consulapi.QueryOptions{WaitIndex: index}).WithContext(c.ctx))
+		if c.ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			c.cc.ReportError(fmt.Errorf("consul resolve %s: %w", c.service, err))
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-time.After(ConsulRetryInterval):
+			}
+			continue
+		}
+		if meta.LastIndex < index {
+			index = 0 //index went backwards,such as after a consul snapshot restore
+			continue
+		}
+		if meta.LastIndex == index {
+			continue //wait time elapsed without change
+		}
+		index = meta.LastIndex
+		c.update(entries)
+	}
+}
+
+func (c *consulResolver) update(entries []*consulapi.ServiceEntry) {
+	var address []resolver.Address
+	for _, entry := range entries {
+		if key, ok := entry.Service.Meta[consulMetaUniqueKey]; ok && key != c.uniqueKey {
+			continue
+		}
+		host := entry.Service.Address
+		if host == "" {
+			host = entry.Node.Address
+		}
+		instance := instanceFromMetadata(entry.Service.Weights.Passing, entry.Service.Meta, consulMetadataTagPrefix)
+		addr := resolver.Address{Addr: net.JoinHostPort(host, strconv.Itoa(entry.Service.Port))}
+		address = append(address, WithInstance(addr, instance))
+	}
+	sortAddresses(address)
+	err := c.cc.UpdateState(resolver.State{Addresses: address})
+	if err != nil {
+		c.cc.ReportError(err)
+	}
+}
@@ -0,0 +1,177 @@
+package grpc_service
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"net/http"
+	"net/http/httptest"
+	neturl "net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/no-mole/neptune/config"
+	"github.com/no-mole/neptune/json"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/resolver"
+)
+
+// fakeConsulAgent consul agent http api of service registration,ttl checks and health blocking queries
+type fakeConsulAgent struct {
+	mu       sync.Mutex
+	index    uint64
+	services map[string]*consulapi.AgentServiceRegistration
+	status   map[string]string
+	changed  chan struct{}
+}
+
+func (f *fakeConsulAgent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Consul-LastContact", "0")
+	f.mu.Lock()
+	switch {
+	case r.URL.Path == "/v1/agent/service/register":
+		reg := &consulapi.AgentServiceRegistration{}
+		_ = stdjson.NewDecoder(r.Body).Decode(reg)
+		f.services[reg.ID] = reg
+		f.status[reg.Check.CheckID] = reg.Check.Status
+		f.notify()
+	case strings.HasPrefix(r.URL.Path, "/v1/agent/service/deregister/"):
+		id := strings.TrimPrefix(r.URL.Path, "/v1/agent/service/deregister/")
+		delete(f.services, id)
+		delete(f.status, consulCheckId(id))
+		f.notify()
+	case strings.HasPrefix(r.URL.Path, "/v1/agent/check/update/"):
+		id := strings.TrimPrefix(r.URL.Path, "/v1/agent/check/update/")
+		if _, ok := f.status[id]; !ok {
+			f.mu.Unlock()
+			http.Error(w, "Unknown check ID", http.StatusNotFound)
+			return
+		}
+		update := &struct{ Status string }{}
+		_ = stdjson.NewDecoder(r.Body).Decode(update)
+		if f.status[id] != update.Status {
+			f.status[id] = update.Status
+			f.notify()
+		}
+	case strings.HasPrefix(r.URL.Path, "/v1/health/service/"):
+		index, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64)
+		if index >= f.index {
+			changed := f.changed
+			f.mu.Unlock()
+			select {
+			case <-changed:
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+				return
+			}
+			f.mu.Lock()
+		}
+		name := strings.TrimPrefix(r.URL.Path, "/v1/health/service/")
+		entries := []*consulapi.ServiceEntry{}
+		for id, reg := range f.services {
+			if reg.Name != name || f.status[consulCheckId(id)] != consulapi.HealthPassing {
+				continue
+			}
+			entries = append(entries, &consulapi.ServiceEntry{
+				Node: &consulapi.Node{Address: "127.0.0.1"},
+				Service: &consulapi.AgentService{ID: id, Service: reg.Name, Address: reg.Address, Port: reg.Port,
+					Meta: reg.Meta, Weights: *reg.Weights},
+			})
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Service.ID < entries[j].Service.ID })
+		w.Header().Set("X-Consul-Index", strconv.FormatUint(f.index, 10))
+		body, _ := json.Marshal(entries)
+		_, _ = w.Write(body)
+	case r.URL.Path == "/v1/status/leader":
+		_, _ = w.Write([]byte(`"127.0.0.1:8300"`))
+	default:
+		http.NotFound(w, r)
+	}
+	f.mu.Unlock()
+}
+
+// notify must be called with lock held
+func (f *fakeConsulAgent) notify() {
+	f.index++
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// restart agent lost its local services
+func (f *fakeConsulAgent) restart() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.services = map[string]*consulapi.AgentServiceRegistration{}
+	f.status = map[string]string{}
+	f.notify()
+}
+
+func TestConsulRegisterAndResolver(t *testing.T) {
+	agent := &fakeConsulAgent{index: 1, services: map[string]*consulapi.AgentServiceRegistration{},
+		status: map[string]string{}, changed: make(chan struct{})}
+	server := httptest.NewServer(agent)
+	defer server.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer SetLocalInstance(LocalInstance())
+	SetLocalInstance(&Instance{Hostname: "h1", Weight: 20, Zone: "z1", Tags: map[string]string{"k": "v"}, StartTime: time.Now()})
+
+	client, err := consulapi.NewClient(config.Trans2ConsulConfig(ctx, &config.Config{Endpoints: server.URL}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl, _ := NewNameTemplate("")
+	register := NewConsulRegister(ctx, client, tpl, 300*time.Millisecond, time.Minute)
+	defer register.Close()
+	md := NewServiceMetadata(&grpc_health_v1.Health_ServiceDesc, "v1")
+	for _, ep := range []string{"10.0.0.1:9000", "10.0.0.2:9000"} {
+		if err = register.Register(ctx, md, ep); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	builder := &ConsulResolverBuilder{ctx: ctx, client: client, name: tpl}
+	cc := &fakeClientConn{states: make(chan resolver.State, 10)}
+	target := resolver.Target{URL: neturl.URL{Scheme: ResolverConsulScheme, Path: md.UniqueKey()}}
+	r, err := builder.Build(target, cc, resolver.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	expect := func(addrs ...string) resolver.State {
+		t.Helper()
+		deadline := time.After(5 * time.Second)
+		for {
+			select {
+			case state := <-cc.states:
+				var got []string
+				for _, addr := range state.Addresses {
+					got = append(got, addr.Addr)
+				}
+				if strings.Join(got, ",") == strings.Join(addrs, ",") {
+					return state
+				}
+			case <-deadline:
+				t.Fatalf("addresses %v not resolved", addrs)
+			}
+		}
+	}
+	state := expect("10.0.0.1:9000", "10.0.0.2:9000")
+	if instance := InstanceFromAddress(state.Addresses[0]); instance.GetWeight() != 20 || instance.Zone != "z1" || instance.Tags["k"] != "v" {
+		t.Fatalf("unexpected instance %+v", instance)
+	}
+
+	if err = register.Unregister(ctx, md, "10.0.0.2:9000"); err != nil {
+		t.Fatal(err)
+	}
+	expect("10.0.0.1:9000")
+
+	//registered again by heartbeat after agent lost services
+	agent.restart()
+	expect()
+	expect("10.0.0.1:9000")
+}
@@ -0,0 +1,186 @@
+package grpc_service
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/no-mole/neptune/config"
+	"github.com/no-mole/neptune/logger"
+	"google.golang.org/grpc/resolver"
+)
+
+const ResolverDnsScheme = "neptune-dns"
+
+var (
+	// DefaultDnsRefreshInterval interval to refresh records when refresh_interval setting is not set
+	DefaultDnsRefreshInterval = 30 * time.Second
+	// DefaultDnsPort port of A/AAAA records when port setting is not set
+	DefaultDnsPort = "8080"
+)
+
+// DnsResolverConfig settings of dns register type
+type DnsResolverConfig struct {
+	// Domain appended to the templated name,such as default.svc.cluster.local
+	Domain string
+	// SrvService look up _{SrvService}._tcp.{name} SRV records when set,otherwise A/AAAA records with Port
+	SrvService string
+	Port       string
+	// RefreshInterval records are refreshed periodically,dns has no change notification
+	RefreshInterval time.Duration
+	NameTemplate    *NameTemplate
+	Resolver        *net.Resolver
+}
+
+// DnsResolverConfigFromConfig domain,srv_service,port,refresh_interval and name_template settings of register config
+func DnsResolverConfigFromConfig(conf *config.Config) (*DnsResolverConfig, error) {
+	tpl, err := NewNameTemplate(conf.Settings["name_template"])
+	if err != nil {
+		return nil, err
+	}
+	c := &DnsResolverConfig{
+		Domain:          conf.Settings["domain"],
+		SrvService:      conf.Settings["srv_service"],
+		Port:            conf.Settings["port"],
+		RefreshInterval: DefaultDnsRefreshInterval,
+		NameTemplate:    tpl,
+		Resolver:        net.DefaultResolver,
+	}
+	if c.Port == "" {
+		c.Port = DefaultDnsPort
+	}
+	if interval, err := time.ParseDuration(conf.Settings["refresh_interval"]); err == nil && interval > 0 {
+		c.RefreshInterval = interval
+	}
+	return c, nil
+}
+
+// RegisterDnsResolverBuilder 创建一个dns解析器构建器，解析器schema为 ResolverDnsScheme
+func RegisterDnsResolverBuilder(ctx context.Context, conf *DnsResolverConfig) resolver.Builder {
+	builder := &DnsResolverBuilder{ctx: ctx, conf: conf}
+	resolver.Register(builder)
+	return builder
+}
+
+type DnsResolverBuilder struct {
+	ctx  context.Context
+	conf *DnsResolverConfig
+}
+
+func (d *DnsResolverBuilder) Scheme() string {
+	return ResolverDnsScheme
+}
+
+func (d *DnsResolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	// "neptune-dns:///zeus/zeus.proto/zeus.ZeusService/v1" resolves zeus-zeusservice-v1.{domain}
+	name, err := d.conf.NameTemplate.Name(target.Endpoint())
+	if err != nil {
+		return nil, err
+	}
+	if d.conf.Domain != "" {
+		name = name + "." + d.conf.Domain
+	}
+	ctx, cancel := context.WithCancel(d.ctx)
+	r := &dnsResolver{
+		ctx:    ctx,
+		cancel: cancel,
+		conf:   d.conf,
+		name:   name,
+		cc:     cc,
+		now:    make(chan struct{}, 1),
+	}
+	r.wg.Add(1)
+	go r.watch()
+	return r, nil
+}
+
+type dnsResolver struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	conf   *DnsResolverConfig
+	name   string
+	cc     resolver.ClientConn
+	now    chan struct{}
+	wg     sync.WaitGroup
+}
+
+func (d *dnsResolver) ResolveNow(_ resolver.ResolveNowOptions) {
+	select {
+	case d.now <- struct{}{}:
+	default:
+	}
+}
+
+func (d *dnsResolver) Close() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+func (d *dnsResolver) watch() {
+	defer d.wg.Done()
+	ticker := time.NewTicker(d.conf.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		d.resolve()
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.now:
+		}
+	}
+}
+
+func (d *dnsResolver) resolve() {
+	address, err := d.lookup()
+	if err != nil {
+		d.cc.ReportError(err)
+		return
+	}
+	sortAddresses(address)
+	err = d.cc.UpdateState(resolver.State{Addresses: address})
+	if err != nil {
+		d.cc.ReportError(err)
+	}
+}
+
+// lookup SRV weights are carried as instance weights for the weighted balancer,
+// SRV targets failed to resolve are skipped unless none of them resolves
+func (d *dnsResolver) lookup() ([]resolver.Address, error) {
+	if d.conf.SrvService == "" {
+		hosts, err := d.conf.Resolver.LookupHost(d.ctx, d.name)
+		if err != nil {
+			return nil, err
+		}
+		address := make([]resolver.Address, 0, len(hosts))
+		for _, host := range hosts {
+			address = append(address, resolver.Address{Addr: net.JoinHostPort(host, d.conf.Port)})
+		}
+		return address, nil
+	}
+	_, srvs, err := d.conf.Resolver.LookupSRV(d.ctx, d.conf.SrvService, "tcp", d.name)
+	if err != nil {
+		return nil, err
+	}
+	var address []resolver.Address
+	var lastErr error
+	for _, srv := range srvs {
+		hosts, err := d.conf.Resolver.LookupHost(d.ctx, srv.Target)
+		if err != nil {
+			logger.Warning(d.ctx, "dns resolver lookup srv target,skipped", err,
+				logger.WithField("name", d.name), logger.WithField("target", srv.Target))
+			lastErr = err
+			continue
+		}
+		for _, host := range hosts {
+			addr := resolver.Address{Addr: net.JoinHostPort(host, strconv.Itoa(int(srv.Port)))}
+			address = append(address, WithInstance(addr, &Instance{Hostname: srv.Target, Weight: int(srv.Weight)}))
+		}
+	}
+	if len(address) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return address, nil
+}
@@ -0,0 +1,146 @@
+package grpc_service
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+	"google.golang.org/grpc/resolver"
+)
+
+// fakeDnsServer answers A and SRV questions over udp,unknown names are NXDOMAIN
+type fakeDnsServer struct {
+	conn net.PacketConn
+	a    map[string][]string
+	srv  map[string][]dnsmessage.SRVResource
+}
+
+func (f *fakeDnsServer) serve() {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := f.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		msg := &dnsmessage.Message{}
+		if msg.Unpack(buf[:n]) != nil || len(msg.Questions) != 1 {
+			continue
+		}
+		q := msg.Questions[0]
+		name := q.Name.String()
+		msg.Header.Response, msg.Header.Authoritative = true, true
+		_, isA := f.a[name]
+		_, isSrv := f.srv[name]
+		switch {
+		case q.Type == dnsmessage.TypeA && isA:
+			for _, ip := range f.a[name] {
+				a := &dnsmessage.AResource{}
+				copy(a.A[:], net.ParseIP(ip).To4())
+				msg.Answers = append(msg.Answers, dnsmessage.Resource{
+					Header: dnsmessage.ResourceHeader{Name: q.Name, Type: q.Type, Class: q.Class, TTL: 30},
+					Body:   a,
+				})
+			}
+		case q.Type == dnsmessage.TypeSRV && isSrv:
+			for i := range f.srv[name] {
+				msg.Answers = append(msg.Answers, dnsmessage.Resource{
+					Header: dnsmessage.ResourceHeader{Name: q.Name, Type: q.Type, Class: q.Class, TTL: 30},
+					Body:   &f.srv[name][i],
+				})
+			}
+		case isA || isSrv:
+			//no records of the type,such as AAAA
+		default:
+			msg.Header.RCode = dnsmessage.RCodeNameError
+		}
+		body, err := msg.Pack()
+		if err != nil {
+			continue
+		}
+		_, _ = f.conn.WriteTo(body, addr)
+	}
+}
+
+func (f *fakeDnsServer) resolver() *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "udp", f.conn.LocalAddr().String())
+		},
+	}
+}
+
+func TestDnsResolver(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	server := &fakeDnsServer{
+		conn: conn,
+		a: map[string][]string{
+			"zeus-zeusservice-v1.svc.test.": {"10.0.0.2", "10.0.0.1"},
+			"zeus-0.svc.test.":              {"10.0.1.1"},
+			"zeus-1.svc.test.":              {"10.0.1.2"},
+		},
+		srv: map[string][]dnsmessage.SRVResource{
+			"_grpc._tcp.zeus-zeusservice-v1.svc.test.": {
+				{Priority: 10, Weight: 30, Port: 9000, Target: dnsmessage.MustNewName("zeus-0.svc.test.")},
+				{Priority: 10, Weight: 10, Port: 9001, Target: dnsmessage.MustNewName("zeus-1.svc.test.")},
+				//target not resolvable is skipped
+				{Priority: 10, Weight: 10, Port: 9000, Target: dnsmessage.MustNewName("zeus-2.svc.test.")},
+			},
+		},
+	}
+	go server.serve()
+
+	tpl, err := NewNameTemplate("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolve := func(srvService string) []resolver.Address {
+		t.Helper()
+		builder := &DnsResolverBuilder{ctx: context.Background(), conf: &DnsResolverConfig{
+			Domain:          "svc.test",
+			SrvService:      srvService,
+			Port:            "8080",
+			RefreshInterval: time.Hour,
+			NameTemplate:    tpl,
+			Resolver:        server.resolver(),
+		}}
+		cc := &fakeClientConn{states: make(chan resolver.State, 1)}
+		target := resolver.Target{URL: url.URL{Scheme: ResolverDnsScheme, Path: "/zeus/zeus.proto/zeus.ZeusService/v1"}}
+		r, err := builder.Build(target, cc, resolver.BuildOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		select {
+		case state := <-cc.states:
+			return state.Addresses
+		case <-time.After(5 * time.Second):
+			t.Fatal("addresses not resolved")
+		}
+		return nil
+	}
+
+	address := resolve("")
+	if len(address) != 2 || address[0].Addr != "10.0.0.1:8080" || address[1].Addr != "10.0.0.2:8080" {
+		t.Fatalf("unexpected A addresses %v", address)
+	}
+
+	//zeus-2 fails to resolve and is skipped
+	address = resolve("grpc")
+	if len(address) != 2 || address[0].Addr != "10.0.1.1:9000" || address[1].Addr != "10.0.1.2:9001" {
+		t.Fatalf("unexpected SRV addresses %v", address)
+	}
+	for i, want := range []*Instance{{Hostname: "zeus-0.svc.test.", Weight: 30}, {Hostname: "zeus-1.svc.test.", Weight: 10}} {
+		instance := InstanceFromAddress(address[i])
+		if instance.Hostname != want.Hostname || instance.GetWeight() != want.Weight {
+			t.Errorf("SRV weight should be carried as instance weight,got %+v", instance)
+		}
+	}
+}
@@ -6,7 +6,6 @@ import (
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"google.golang.org/grpc/resolver"
 	"net"
-	"os"
 	"strings"
 	"sync"
 	"time"
@@ -81,13 +80,12 @@ func (e *etcdResolver) ResolveNow(_ resolver.ResolveNowOptions) {
 		if err != nil {
 			continue
 		}
-		address = append(address, resolver.Address{
-			Addr: endpoint,
-		})
+		address = append(address, WithInstance(resolver.Address{Addr: endpoint}, DecodeInstance(kv.Value)))
 	}
 	if len(address) == 0 {
 		return
 	}
+	sortAddresses(address)
 	err = e.cc.UpdateState(resolver.State{
 		Addresses: address,
 	})
@@ -184,13 +182,24 @@ func (e *EtcdRegister) Unregister(ctx context.Context, service Metadata, endpoin
 	return err
 }
 
+// Ping check connectivity by counting keys in namespace
+func (e *EtcdRegister) Ping(ctx context.Context) error {
+	_, err := e.client.Get(ctx, fmt.Sprintf("/%s/", e.namespace), clientv3.WithPrefix(), clientv3.WithCountOnly())
+	return err
+}
+
+// DiscoveryKey etcd key of the registered endpoint
+func (e *EtcdRegister) DiscoveryKey(service Metadata, endpoint string) string {
+	return e.key(service, endpoint)
+}
+
 func (e *EtcdRegister) key(service Metadata, endpoint string) string {
 	return fmt.Sprintf("/%s/%s/%s", e.namespace, service.UniqueKey(), endpoint)
 }
 
+// value json of LocalInstance
 func (e *EtcdRegister) value() string {
-	name, _ := os.Hostname()
-	return name
+	return LocalInstance().Encode()
 }
 
 func (e *EtcdRegister) leaseKeepalive(ctx context.Context) error {
@@ -250,9 +259,12 @@ func (e *EtcdRegister) reRegisterServices() {
 
 type RegisterServices struct {
 	services map[string]*ServiceInfo
+	mu       sync.RWMutex
 }
 
 func (r *RegisterServices) Range(fn func(instance Metadata, endpoint string) error) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	for _, register := range r.services {
 		for ep := range register.Endpoints {
 			err := fn(register.Item, ep)
@@ -265,6 +277,11 @@ func (r *RegisterServices) Range(fn func(instance Metadata, endpoint string) err
 }
 
 func (r *RegisterServices) Put(instance Metadata, endpoint string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.services == nil {
+		r.services = map[string]*ServiceInfo{}
+	}
 	item, ok := r.services[instance.UniqueKey()]
 	if !ok {
 		item = &ServiceInfo{
@@ -276,6 +293,8 @@ func (r *RegisterServices) Put(instance Metadata, endpoint string) {
 }
 
 func (r *RegisterServices) Del(instance Metadata, endpoint string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	item, ok := r.services[instance.UniqueKey()]
 	if !ok {
 		return
@@ -0,0 +1,186 @@
+package grpc_service
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/no-mole/neptune/config"
+	"github.com/no-mole/neptune/json"
+	"google.golang.org/grpc/resolver"
+)
+
+// DefaultWeight weight of instances registered without weight or by old versions
+var DefaultWeight = 10
+
+// Instance metadata of a registered endpoint,stored as json value in etcd and as instance metadata in nacos
+type Instance struct {
+	Hostname  string            `json:"hostname,omitempty"`
+	Weight    int               `json:"weight,omitempty"`
+	Zone      string            `json:"zone,omitempty"`
+	Version   string            `json:"version,omitempty"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	StartTime time.Time         `json:"startTime,omitempty"`
+}
+
+var localInstance = newLocalInstance()
+
+func newLocalInstance() *Instance {
+	hostname, _ := os.Hostname()
+	return &Instance{
+		Hostname:  hostname,
+		Weight:    DefaultWeight,
+		StartTime: time.Now(),
+	}
+}
+
+// SetLocalInstance metadata registered with endpoints of this process,zone of it is preferred by the weighted balancer
+func SetLocalInstance(instance *Instance) {
+	localInstance = instance
+}
+
+// LocalInstance metadata registered with endpoints of this process
+func LocalInstance() *Instance {
+	return localInstance
+}
+
+// InstanceFromConfig local instance with weight,zone,version and tags(k1=v1,k2=v2) settings of register config
+func InstanceFromConfig(conf *config.Config) *Instance {
+	instance := newLocalInstance()
+	if weight, err := strconv.Atoi(conf.Settings["weight"]); err == nil && weight > 0 {
+		instance.Weight = weight
+	}
+	instance.Zone = conf.Settings["zone"]
+	instance.Version = conf.Settings["version"]
+	for _, pair := range strings.Split(conf.Settings["tags"], ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(pair), "=")
+		if k == "" {
+			continue
+		}
+		if instance.Tags == nil {
+			instance.Tags = map[string]string{}
+		}
+		instance.Tags[k] = v
+	}
+	return instance
+}
+
+// GetWeight weight or DefaultWeight when not set
+func (i *Instance) GetWeight() int {
+	if i == nil || i.Weight <= 0 {
+		return DefaultWeight
+	}
+	return i.Weight
+}
+
+// Equal used by grpc to compare address attributes
+func (i *Instance) Equal(o any) bool {
+	other, ok := o.(*Instance)
+	if !ok {
+		return false
+	}
+	if i == nil || other == nil {
+		return i == other
+	}
+	if i.Hostname != other.Hostname || i.Weight != other.Weight || i.Zone != other.Zone ||
+		i.Version != other.Version || !i.StartTime.Equal(other.StartTime) || len(i.Tags) != len(other.Tags) {
+		return false
+	}
+	for k, v := range i.Tags {
+		if ov, ok := other.Tags[k]; !ok || ov != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Encode json value of etcd registration
+func (i *Instance) Encode() string {
+	body, _ := json.Marshal(i)
+	return string(body)
+}
+
+// DecodeInstance parse etcd registration value,values of old versions only hold the hostname
+func DecodeInstance(value []byte) *Instance {
+	instance := &Instance{}
+	if len(value) > 0 && value[0] == '{' && json.Unmarshal(value, instance) == nil {
+		return instance
+	}
+	return &Instance{Hostname: string(value)}
+}
+
+const (
+	metadataHostname  = "hostname"
+	metadataZone      = "zone"
+	metadataVersion   = "version"
+	metadataStartTime = "startTime"
+
+	nacosMetadataTagPrefix  = "tag."
+	consulMetadataTagPrefix = "tag_" //consul meta keys allow only letters,digits,- and _
+)
+
+// metadata flat string map of nacos instance metadata and consul service meta,
+// weight is carried by the instance weight of registries
+func (i *Instance) metadata(tagPrefix string) map[string]string {
+	md := map[string]string{
+		metadataHostname:  i.Hostname,
+		metadataStartTime: i.StartTime.Format(time.RFC3339Nano),
+	}
+	if i.Zone != "" {
+		md[metadataZone] = i.Zone
+	}
+	if i.Version != "" {
+		md[metadataVersion] = i.Version
+	}
+	for k, v := range i.Tags {
+		md[tagPrefix+k] = v
+	}
+	return md
+}
+
+func instanceFromMetadata(weight int, md map[string]string, tagPrefix string) *Instance {
+	instance := &Instance{
+		Hostname: md[metadataHostname],
+		Weight:   weight,
+		Zone:     md[metadataZone],
+		Version:  md[metadataVersion],
+	}
+	instance.StartTime, _ = time.Parse(time.RFC3339Nano, md[metadataStartTime])
+	for k, v := range md {
+		if tag, ok := strings.CutPrefix(k, tagPrefix); ok {
+			if instance.Tags == nil {
+				instance.Tags = map[string]string{}
+			}
+			instance.Tags[tag] = v
+		}
+	}
+	return instance
+}
+
+// InstanceFromNacos instance of nacos instance weight and metadata
+func InstanceFromNacos(weight float64, md map[string]string) *Instance {
+	return instanceFromMetadata(int(weight), md, nacosMetadataTagPrefix)
+}
+
+type instanceKey struct{}
+
+// WithInstance set instance as balancer attribute of addr
+func WithInstance(addr resolver.Address, instance *Instance) resolver.Address {
+	addr.BalancerAttributes = addr.BalancerAttributes.WithValue(instanceKey{}, instance)
+	return addr
+}
+
+// InstanceFromAddress instance set by resolvers,nil when resolved by other resolvers
+func InstanceFromAddress(addr resolver.Address) *Instance {
+	instance, _ := addr.BalancerAttributes.Value(instanceKey{}).(*Instance)
+	return instance
+}
+
+// sortAddresses resolvers return addresses in a stable order
+func sortAddresses(address []resolver.Address) {
+	sort.Slice(address, func(i, j int) bool {
+		return address[i].Addr < address[j].Addr
+	})
+}
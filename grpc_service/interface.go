@@ -13,7 +13,19 @@ type RegisterInterface interface {
 	Close() error
 }
 
+// Pinger is implemented by registers able to check the connectivity to registry
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// DiscoveryKeyer is implemented by registers able to tell where an endpoint is registered in registry
+type DiscoveryKeyer interface {
+	DiscoveryKey(service Metadata, endpoint string) string
+}
+
 var (
+	registered = &RegisterServices{}
+
 	instance                   RegisterInterface = &nop{}
 	errorDefaultRegisterNotSet                   = errors.New("default register not set")
 )
@@ -32,6 +44,7 @@ func Register(ctx context.Context, endpoint string, mds ...Metadata) error {
 		if err != nil {
 			return err
 		}
+		registered.Put(md, endpoint)
 	}
 	return nil
 }
@@ -46,10 +59,39 @@ func Unregister(ctx context.Context, endpoint string, mds ...Metadata) error {
 		if err != nil {
 			return err
 		}
+		registered.Del(md, endpoint)
 	}
 	return nil
 }
 
+type RegisteredService struct {
+	ServiceName  string `json:"serviceName"`
+	Version      string `json:"version"`
+	UniqueKey    string `json:"uniqueKey"`
+	Endpoint     string `json:"endpoint"`
+	DiscoveryKey string `json:"discoveryKey"`
+}
+
+// Registered services registered by the default register
+func Registered() []*RegisteredService {
+	var list []*RegisteredService
+	_ = registered.Range(func(md Metadata, endpoint string) error {
+		key := md.UniqueKey() + "/" + endpoint
+		if keyer, ok := instance.(DiscoveryKeyer); ok {
+			key = keyer.DiscoveryKey(md, endpoint)
+		}
+		list = append(list, &RegisteredService{
+			ServiceName:  md.ServiceDesc().ServiceName,
+			Version:      md.Version(),
+			UniqueKey:    md.UniqueKey(),
+			Endpoint:     endpoint,
+			DiscoveryKey: key,
+		})
+		return nil
+	})
+	return list
+}
+
 // Close 关闭默认注册器
 func Close() error {
 	return instance.Close()
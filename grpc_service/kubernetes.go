@@ -0,0 +1,340 @@
+package grpc_service
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/no-mole/neptune/config"
+	"github.com/no-mole/neptune/json"
+	"github.com/no-mole/neptune/logger"
+	"google.golang.org/grpc/resolver"
+)
+
+const ResolverKubernetesScheme = "neptune-kubernetes"
+
+const (
+	kubernetesServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	kubernetesServiceNameLabel  = "kubernetes.io/service-name"
+)
+
+// KubernetesWatchRetryInterval interval to relist endpoint slices after a watch failed
+var KubernetesWatchRetryInterval = 5 * time.Second
+
+// KubernetesResolverConfig settings of kubernetes register type
+type KubernetesResolverConfig struct {
+	// Server api server url,in cluster https://$KUBERNETES_SERVICE_HOST:$KUBERNETES_SERVICE_PORT when empty
+	Server    string
+	Token     string
+	Namespace string
+	// PortName port of endpoint slices used,the first port when empty
+	PortName     string
+	NameTemplate *NameTemplate
+	HttpClient   *http.Client
+}
+
+// KubernetesResolverConfigFromConfig endpoints as api server,password as bearer token,namespace,
+// and token_file,ca_file,insecure_skip_verify,port_name,name_template settings of register config.
+// Service account token,ca and namespace are used when running in cluster
+func KubernetesResolverConfigFromConfig(conf *config.Config) (*KubernetesResolverConfig, error) {
+	tpl, err := NewNameTemplate(conf.Settings["name_template"])
+	if err != nil {
+		return nil, err
+	}
+	c := &KubernetesResolverConfig{
+		Server:       strings.TrimSuffix(strings.Split(conf.Endpoints, ",")[0], "/"),
+		Token:        conf.Password,
+		Namespace:    conf.Namespace,
+		PortName:     conf.Settings["port_name"],
+		NameTemplate: tpl,
+	}
+	if c.Server == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return nil, errors.New("kubernetes api server not configured and not running in cluster")
+		}
+		c.Server = "https://" + net.JoinHostPort(host, port)
+	}
+	if c.Token == "" {
+		tokenFile := conf.Settings["token_file"]
+		if tokenFile == "" {
+			tokenFile = kubernetesServiceAccountDir + "/token"
+		}
+		if token, err := os.ReadFile(tokenFile); err == nil {
+			c.Token = strings.TrimSpace(string(token))
+		}
+	}
+	if c.Namespace == "" {
+		if namespace, err := os.ReadFile(kubernetesServiceAccountDir + "/namespace"); err == nil {
+			c.Namespace = strings.TrimSpace(string(namespace))
+		} else {
+			c.Namespace = "default"
+		}
+	}
+	tlsConfig := &tls.Config{}
+	tlsConfig.InsecureSkipVerify, _ = strconv.ParseBool(conf.Settings["insecure_skip_verify"])
+	caFile := conf.Settings["ca_file"]
+	if caFile == "" {
+		caFile = kubernetesServiceAccountDir + "/ca.crt"
+	}
+	if ca, err := os.ReadFile(caFile); err == nil {
+		tlsConfig.RootCAs = x509.NewCertPool()
+		tlsConfig.RootCAs.AppendCertsFromPEM(ca)
+	}
+	c.HttpClient = &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment}}
+	return c, nil
+}
+
+// RegisterKubernetesResolverBuilder 创建一个kubernetes解析器构建器，解析器schema为 ResolverKubernetesScheme
+func RegisterKubernetesResolverBuilder(ctx context.Context, conf *KubernetesResolverConfig) resolver.Builder {
+	builder := &KubernetesResolverBuilder{ctx: ctx, conf: conf}
+	resolver.Register(builder)
+	return builder
+}
+
+type KubernetesResolverBuilder struct {
+	ctx  context.Context
+	conf *KubernetesResolverConfig
+}
+
+func (k *KubernetesResolverBuilder) Scheme() string {
+	return ResolverKubernetesScheme
+}
+
+func (k *KubernetesResolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	// "neptune-kubernetes:///zeus/zeus.proto/zeus.ZeusService/v1" resolves endpoint slices of service zeus-zeusservice-v1
+	name, err := k.conf.NameTemplate.Name(target.Endpoint())
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(k.ctx)
+	r := &kubernetesResolver{
+		ctx:     ctx,
+		cancel:  cancel,
+		conf:    k.conf,
+		service: name,
+		cc:      cc,
+	}
+	r.wg.Add(1)
+	go r.watch()
+	return r, nil
+}
+
+// endpointSlice fields of discovery.k8s.io/v1 EndpointSlice used by resolver
+type endpointSlice struct {
+	Metadata struct {
+		Name            string `json:"name"`
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+	AddressType string `json:"addressType"`
+	Endpoints   []struct {
+		Addresses  []string `json:"addresses"`
+		Conditions struct {
+			Ready *bool `json:"ready"`
+		} `json:"conditions"`
+		Hostname  string `json:"hostname"`
+		TargetRef *struct {
+			Name string `json:"name"`
+		} `json:"targetRef"`
+		Zone string `json:"zone"`
+	} `json:"endpoints"`
+	Ports []struct {
+		Name *string `json:"name"`
+		Port *int32  `json:"port"`
+	} `json:"ports"`
+}
+
+type endpointSliceList struct {
+	Metadata struct {
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+	Items []*endpointSlice `json:"items"`
+}
+
+type kubernetesStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type kubernetesResolver struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	conf    *KubernetesResolverConfig
+	service string
+	cc      resolver.ClientConn
+	wg      sync.WaitGroup
+
+	slices map[string]*endpointSlice
+}
+
+// ResolveNow endpoint slices are watched,changes are pushed without resolving
+func (k *kubernetesResolver) ResolveNow(_ resolver.ResolveNowOptions) {}
+
+func (k *kubernetesResolver) Close() {
+	k.cancel()
+	k.wg.Wait()
+}
+
+// watch list and watch endpoint slices of service,relist after watch failed or expired
+func (k *kubernetesResolver) watch() {
+	defer k.wg.Done()
+	for {
+		resourceVersion, err := k.list()
+		if err == nil {
+			err = k.watchFrom(resourceVersion)
+		}
+		if k.ctx.Err() != nil {
+			return
+		}
+		//watch closed by api server is relisted after the interval as well
+		if err != nil {
+			logger.Warning(k.ctx, "kubernetes resolver watch", err, logger.WithField("service", k.service))
+			k.cc.ReportError(err)
+		}
+		select {
+		case <-k.ctx.Done():
+			return
+		case <-time.After(KubernetesWatchRetryInterval):
+		}
+	}
+}
+
+func (k *kubernetesResolver) list() (string, error) {
+	resp, err := k.request(url.Values{})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	list := &endpointSliceList{}
+	if err = stdjson.NewDecoder(resp.Body).Decode(list); err != nil {
+		return "", err
+	}
+	k.slices = make(map[string]*endpointSlice, len(list.Items))
+	for _, slice := range list.Items {
+		k.slices[slice.Metadata.Name] = slice
+	}
+	k.update()
+	return list.Metadata.ResourceVersion, nil
+}
+
+func (k *kubernetesResolver) watchFrom(resourceVersion string) error {
+	resp, err := k.request(url.Values{
+		"watch":               {"true"},
+		"resourceVersion":     {resourceVersion},
+		"allowWatchBookmarks": {"true"},
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		raw := &struct {
+			Type   string             `json:"type"`
+			Object stdjson.RawMessage `json:"object"`
+		}{}
+		if err = json.Unmarshal(scanner.Bytes(), raw); err != nil {
+			return err
+		}
+		switch raw.Type {
+		case "ERROR":
+			status := &kubernetesStatus{}
+			_ = json.Unmarshal(raw.Object, status)
+			return fmt.Errorf("kubernetes watch endpoint slices of %s: %d %s", k.service, status.Code, status.Message)
+		case "BOOKMARK":
+			continue
+		}
+		slice := &endpointSlice{}
+		if err = json.Unmarshal(raw.Object, slice); err != nil {
+			return err
+		}
+		if raw.Type == "DELETED" {
+			delete(k.slices, slice.Metadata.Name)
+		} else {
+			k.slices[slice.Metadata.Name] = slice
+		}
+		k.update()
+	}
+	return scanner.Err()
+}
+
+func (k *kubernetesResolver) request(query url.Values) (*http.Response, error) {
+	query.Set("labelSelector", kubernetesServiceNameLabel+"="+k.service)
+	u := fmt.Sprintf("%s/apis/discovery.k8s.io/v1/namespaces/%s/endpointslices?%s", k.conf.Server, url.PathEscape(k.conf.Namespace), query.Encode())
+	req, err := http.NewRequestWithContext(k.ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if k.conf.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+k.conf.Token)
+	}
+	client := k.conf.HttpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		status := &kubernetesStatus{}
+		_ = stdjson.NewDecoder(resp.Body).Decode(status)
+		return nil, fmt.Errorf("kubernetes list endpoint slices of %s: %s %s", k.service, resp.Status, status.Message)
+	}
+	return resp, nil
+}
+
+// update ready endpoints of all slices,zone and pod name are carried as instance metadata
+func (k *kubernetesResolver) update() {
+	var address []resolver.Address
+	for _, slice := range k.slices {
+		port, ok := k.port(slice)
+		if !ok {
+			continue
+		}
+		for _, ep := range slice.Endpoints {
+			if ep.Conditions.Ready != nil && !*ep.Conditions.Ready {
+				continue
+			}
+			instance := &Instance{Hostname: ep.Hostname, Zone: ep.Zone}
+			if ep.TargetRef != nil {
+				instance.Hostname = ep.TargetRef.Name
+			}
+			for _, addr := range ep.Addresses {
+				address = append(address, WithInstance(resolver.Address{Addr: net.JoinHostPort(addr, port)}, instance))
+			}
+		}
+	}
+	sortAddresses(address)
+	err := k.cc.UpdateState(resolver.State{Addresses: address})
+	if err != nil {
+		k.cc.ReportError(err)
+	}
+}
+
+func (k *kubernetesResolver) port(slice *endpointSlice) (string, bool) {
+	for _, p := range slice.Ports {
+		if p.Port == nil {
+			continue
+		}
+		if k.conf.PortName == "" || (p.Name != nil && *p.Name == k.conf.PortName) {
+			return strconv.Itoa(int(*p.Port)), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,120 @@
+package grpc_service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/resolver"
+)
+
+type fakeClientConn struct {
+	resolver.ClientConn
+	states chan resolver.State
+}
+
+func (f *fakeClientConn) UpdateState(state resolver.State) error {
+	f.states <- state
+	return nil
+}
+
+func (f *fakeClientConn) ReportError(error) {}
+
+func endpointSliceJson(name string, port int, endpoints ...string) string {
+	return fmt.Sprintf(`{"metadata":{"name":%q},"addressType":"IPv4","ports":[{"name":"grpc","port":%d}],"endpoints":[%s]}`,
+		name, port, strings.Join(endpoints, ","))
+}
+
+func TestKubernetesResolver(t *testing.T) {
+	const service = "zeus-zeusservice-v1"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/apis/discovery.k8s.io/v1/namespaces/dev/endpointslices" ||
+			r.URL.Query().Get("labelSelector") != kubernetesServiceNameLabel+"="+service ||
+			r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, `{"message":"unexpected request"}`, http.StatusBadRequest)
+			return
+		}
+		if r.URL.Query().Get("watch") != "true" {
+			_, _ = fmt.Fprintf(w, `{"metadata":{"resourceVersion":"10"},"items":[%s,%s]}`,
+				endpointSliceJson("a", 9000, `{"addresses":["10.0.0.1"],"zone":"z1","targetRef":{"name":"pod-1"}}`,
+					`{"addresses":["10.0.0.2"],"conditions":{"ready":false}}`),
+				endpointSliceJson("b", 9000, `{"addresses":["10.0.0.3"],"conditions":{"ready":true}}`))
+			return
+		}
+		if r.URL.Query().Get("resourceVersion") != "10" {
+			http.Error(w, `{"message":"unexpected resource version"}`, http.StatusBadRequest)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"type":"MODIFIED","object":%s}`+"\n",
+			endpointSliceJson("a", 9000, `{"addresses":["10.0.0.1"]}`, `{"addresses":["10.0.0.2"]}`))
+		_, _ = fmt.Fprintf(w, `{"type":"DELETED","object":%s}`+"\n", endpointSliceJson("b", 9000))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	tpl, err := NewNameTemplate("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	builder := &KubernetesResolverBuilder{ctx: context.Background(), conf: &KubernetesResolverConfig{
+		Server:       server.URL,
+		Token:        "token",
+		Namespace:    "dev",
+		PortName:     "grpc",
+		NameTemplate: tpl,
+	}}
+	cc := &fakeClientConn{states: make(chan resolver.State, 10)}
+	target := resolver.Target{URL: url.URL{Scheme: ResolverKubernetesScheme, Path: "/zeus/zeus.proto/zeus.ZeusService/v1"}}
+	r, err := builder.Build(target, cc, resolver.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	expected := [][]string{
+		{"10.0.0.1:9000", "10.0.0.3:9000"}, //listed,not ready endpoint skipped
+		{"10.0.0.1:9000", "10.0.0.2:9000", "10.0.0.3:9000"},
+		{"10.0.0.1:9000", "10.0.0.2:9000"},
+	}
+	for i, addrs := range expected {
+		select {
+		case state := <-cc.states:
+			if len(state.Addresses) != len(addrs) {
+				t.Fatalf("update %d: unexpected addresses %v", i, state.Addresses)
+			}
+			for j, addr := range addrs {
+				if state.Addresses[j].Addr != addr {
+					t.Fatalf("update %d: unexpected addresses %v", i, state.Addresses)
+				}
+			}
+			if i == 0 {
+				instance := InstanceFromAddress(state.Addresses[0])
+				if instance.Zone != "z1" || instance.Hostname != "pod-1" {
+					t.Fatalf("unexpected instance %+v", instance)
+				}
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("update %d not received", i)
+		}
+	}
+}
+
+func TestNameTemplate(t *testing.T) {
+	tpl, err := NewNameTemplate(`{{ .Service | dns }}.{{ .Version }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := tpl.Name("/zeus/zeus.proto/zeus.ZeusService/v1")
+	if err != nil || name != "zeus-zeusservice.v1" {
+		t.Fatalf("unexpected name %s %v", name, err)
+	}
+	if _, err = tpl.Name("v1"); err == nil {
+		t.Fatal("invalid unique key accepted")
+	}
+}
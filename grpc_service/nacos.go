@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/nacos-group/nacos-sdk-go/v2/clients/naming_client"
+	"github.com/nacos-group/nacos-sdk-go/v2/common/constant"
 	"github.com/nacos-group/nacos-sdk-go/v2/model"
 	"github.com/nacos-group/nacos-sdk-go/v2/vo"
 	"google.golang.org/grpc/resolver"
@@ -46,7 +47,8 @@ func (n *NacosResolverBuilder) RegisterService(_ context.Context, service Metada
 		Ip:          host,
 		Port:        uint64(portInt),
 		ServiceName: service.UniqueKey(),
-		Weight:      10,
+		Weight:      float64(LocalInstance().GetWeight()),
+		Metadata:    LocalInstance().metadata(nacosMetadataTagPrefix),
 		Enable:      true,
 		Healthy:     true,
 		Ephemeral:   true,
@@ -133,10 +135,9 @@ func (n *nacosResolver) ResolveNow(options resolver.ResolveNowOptions) {
 		if !v.Enable {
 			continue
 		}
-		address = append(address, resolver.Address{
-			Addr: fmt.Sprintf("%s:%d", v.Ip, v.Port),
-		})
+		address = append(address, nacosAddress(v))
 	}
+	sortAddresses(address)
 	err = n.cc.UpdateState(resolver.State{
 		Addresses: address,
 	})
@@ -146,6 +147,13 @@ func (n *nacosResolver) ResolveNow(options resolver.ResolveNowOptions) {
 	}
 }
 
+func nacosAddress(instance model.Instance) resolver.Address {
+	return WithInstance(
+		resolver.Address{Addr: fmt.Sprintf("%s:%d", instance.Ip, instance.Port)},
+		InstanceFromNacos(instance.Weight, instance.Metadata),
+	)
+}
+
 func (n *nacosResolver) Close() {
 	close(n.close)
 }
@@ -165,10 +173,9 @@ func (n *nacosResolver) start() {
 					if !v.Enable {
 						continue
 					}
-					address = append(address, resolver.Address{
-						Addr: fmt.Sprintf("%s:%d", v.Ip, v.Port),
-					})
+					address = append(address, nacosAddress(v))
 				}
+				sortAddresses(address)
 				err = n.cc.UpdateState(resolver.State{
 					Addresses: address,
 				})
@@ -199,6 +206,25 @@ func (n *NacosRegister) Close() error {
 	return nil
 }
 
+// DiscoveryKey nacos service name with group of the registered endpoint
+func (n *NacosRegister) DiscoveryKey(service Metadata, endpoint string) string {
+	groupName := n.groupName
+	if groupName == "" {
+		groupName = constant.DEFAULT_GROUP
+	}
+	return fmt.Sprintf("%s@@%s/%s", groupName, service.UniqueKey(), endpoint)
+}
+
+// Ping check connectivity by listing one service in group
+func (n *NacosRegister) Ping(_ context.Context) error {
+	_, err := n.client.GetAllServicesInfo(vo.GetAllServiceInfoParam{
+		GroupName: n.groupName,
+		PageNo:    1,
+		PageSize:  1,
+	})
+	return err
+}
+
 func (n *NacosRegister) Register(_ context.Context, service Metadata, endpoint string) error {
 	host, port, err := net.SplitHostPort(endpoint)
 	if err != nil {
@@ -212,7 +238,8 @@ func (n *NacosRegister) Register(_ context.Context, service Metadata, endpoint s
 		Ip:          host,
 		Port:        uint64(portInt),
 		ServiceName: service.UniqueKey(),
-		Weight:      10,
+		Weight:      float64(LocalInstance().GetWeight()),
+		Metadata:    LocalInstance().metadata(nacosMetadataTagPrefix),
 		Enable:      true,
 		Healthy:     true,
 		Ephemeral:   true,
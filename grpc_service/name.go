@@ -0,0 +1,76 @@
+package grpc_service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// DefaultNameTemplate maps /zeus/zeus.proto/zeus.ZeusService/v1 to zeus-zeusservice-v1
+var DefaultNameTemplate = `{{ dns .Service }}-{{ dns .Version }}`
+
+// ServiceName fields of Metadata.UniqueKey() available to name templates
+type ServiceName struct {
+	UniqueKey string // /zeus/zeus.proto/zeus.ZeusService/v1
+	Metadata  string // zeus/zeus.proto
+	Service   string // zeus.ZeusService
+	Version   string // v1
+}
+
+var invalidDnsChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+var nameFuncs = template.FuncMap{
+	// dns lower case and replace characters invalid in a dns label with -
+	"dns": func(s string) string {
+		return strings.Trim(invalidDnsChars.ReplaceAllString(strings.ToLower(s), "-"), "-")
+	},
+	"lower":   strings.ToLower,
+	"replace": strings.ReplaceAll,
+}
+
+// NameTemplate maps unique key of service metadata to a dns or kubernetes service name
+type NameTemplate struct {
+	tpl *template.Template
+}
+
+// NewNameTemplate parse text/template text,DefaultNameTemplate when text is empty
+func NewNameTemplate(text string) (*NameTemplate, error) {
+	if text == "" {
+		text = DefaultNameTemplate
+	}
+	tpl, err := template.New("name").Funcs(nameFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse name template: %w", err)
+	}
+	return &NameTemplate{tpl: tpl}, nil
+}
+
+// Name execute template with fields parsed from unique key
+func (n *NameTemplate) Name(uniqueKey string) (string, error) {
+	name, err := ParseServiceName(uniqueKey)
+	if err != nil {
+		return "", err
+	}
+	sb := &strings.Builder{}
+	if err = n.tpl.Execute(sb, name); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// ParseServiceName split /{metadata}/{service}/{version},metadata may contain /
+func ParseServiceName(uniqueKey string) (*ServiceName, error) {
+	key := strings.Trim(uniqueKey, "/")
+	i := strings.LastIndex(key, "/")
+	if i <= 0 {
+		return nil, fmt.Errorf("invalid service unique key [%s]", uniqueKey)
+	}
+	name := &ServiceName{UniqueKey: uniqueKey, Version: key[i+1:]}
+	key = key[:i]
+	if i = strings.LastIndex(key, "/"); i >= 0 {
+		name.Metadata, key = key[:i], key[i+1:]
+	}
+	name.Service = key
+	return name, nil
+}
@@ -3,44 +3,38 @@ package grpc_service
 import (
 	"context"
 	"fmt"
+	consulapi "github.com/hashicorp/consul/api"
 	"github.com/nacos-group/nacos-sdk-go/v2/clients"
 	"github.com/nacos-group/nacos-sdk-go/v2/vo"
 	"github.com/no-mole/neptune/application"
 	"github.com/no-mole/neptune/config"
+	"github.com/no-mole/neptune/health"
 	"github.com/no-mole/neptune/logger"
 	clientv3 "go.etcd.io/etcd/client/v3"
-	"gopkg.in/yaml.v3"
 	"strconv"
+	"time"
 )
 
 // NewPlugin 服务注册、服务发现组件
 func NewPlugin(_ context.Context) application.Plugin {
+	conf := &config.Config{}
 	plg := &Plugin{
-		Plugin: application.NewPluginConfig("grpc-register", &application.PluginConfigOptions{
+		ConfigPlugin: application.NewConfigPlugin("grpc-register", &application.PluginConfigOptions{
 			ConfigName: "register.yaml",
 			ConfigType: "yaml",
 			EnvPrefix:  "",
-		}),
-		config: &config.Config{},
+			FlagPrefix: "register-",
+		}, conf),
+		config: conf,
 	}
-	plg.Flags().StringVar(&plg.config.Type, "register-type", "", "config client type")
-	plg.Flags().StringVar(&plg.config.Endpoints, "register-endpoints", "", "config client endpoints")
-	plg.Flags().StringVar(&plg.config.Namespace, "register-namespace", "", "config client namespace")
-	plg.Flags().StringVar(&plg.config.Username, "register-username", "", "config client username")
-	plg.Flags().StringVar(&plg.config.Password, "register-password", "", "config client password")
-	plg.Flags().StringToStringVar(&plg.config.Settings, "register-settings", nil, "config client settings")
 	return plg
 }
 
 type Plugin struct {
-	application.Plugin
+	*application.ConfigPlugin[config.Config]
 	config *config.Config
 }
 
-func (p *Plugin) Config(ctx context.Context, conf []byte) error {
-	return yaml.Unmarshal(conf, p.config)
-}
-
 func (p *Plugin) Init(ctx context.Context) error {
 	logger.Info(
 		ctx,
@@ -51,14 +45,27 @@ func (p *Plugin) Init(ctx context.Context) error {
 		logger.WithField("grpcRegisterUsername", p.config.Username),
 		logger.WithField("grpcRegisterSettings", p.config.Settings),
 	)
+	SetLocalInstance(InstanceFromConfig(p.config))
 	initFn, ok := registryClientTypes[p.config.Type]
 	if !ok {
 		return fmt.Errorf("unsupported register type:[%s]", p.config.Type)
 	}
-	return initFn(context.Background(), p.config)
+	err := initFn(context.Background(), p.config)
+	if err != nil {
+		return err
+	}
+	if pinger, ok := instance.(Pinger); ok {
+		health.Register(p.Name(), p.config.Type, health.CheckerFunc(pinger.Ping))
+	}
+	return nil
 }
 func (p *Plugin) Run(ctx context.Context) error {
 	<-ctx.Done()
+	return nil
+}
+
+// Stop close the default register after grpc server unregistered its services
+func (p *Plugin) Stop(_ context.Context) error {
 	return Close()
 }
 
@@ -114,4 +121,44 @@ func init() {
 		RegisterNacosResolverBuilder(ctx, cli)
 		return nil
 	})
+	RegistryClientType("consul", func(ctx context.Context, conf *config.Config) error {
+		name, err := NewNameTemplate(conf.Settings["name_template"])
+		if err != nil {
+			return err
+		}
+		cli, err := consulapi.NewClient(config.Trans2ConsulConfig(ctx, conf))
+		if err != nil {
+			return err
+		}
+		ttl := DefaultConsulTTL
+		if ttlInt, _ := strconv.Atoi(conf.Settings["ttl"]); ttlInt > 0 {
+			ttl = time.Duration(ttlInt) * time.Second
+		}
+		deregisterAfter := DefaultConsulDeregisterAfter
+		if d, err := time.ParseDuration(conf.Settings["deregister_after"]); err == nil && d > 0 {
+			deregisterAfter = d
+		}
+		SetDefaultRegister(NewConsulRegister(ctx, cli, name, ttl, deregisterAfter))
+		RegisterConsulResolverBuilder(ctx, cli, name)
+		return nil
+	})
+	//dns and kubernetes resolve services by platform,servers register nothing
+	RegistryClientType("dns", func(ctx context.Context, conf *config.Config) error {
+		resolverConf, err := DnsResolverConfigFromConfig(conf)
+		if err != nil {
+			return err
+		}
+		SetDefaultRegister(&nop{})
+		RegisterDnsResolverBuilder(ctx, resolverConf)
+		return nil
+	})
+	RegistryClientType("kubernetes", func(ctx context.Context, conf *config.Config) error {
+		resolverConf, err := KubernetesResolverConfigFromConfig(conf)
+		if err != nil {
+			return err
+		}
+		SetDefaultRegister(&nop{})
+		RegisterKubernetesResolverBuilder(ctx, resolverConf)
+		return nil
+	})
 }
@@ -0,0 +1,112 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// GrpcCheckInterval interval to refresh statuses of grpc health service
+var GrpcCheckInterval = 5 * time.Second
+
+// GrpcServer grpc.health.v1 service backed by default registry.
+// Service "" reports the whole readiness,each registered grpc service and component reports its own status.
+// Statuses are refreshed on every state change of components and every GrpcCheckInterval for checkers.
+type GrpcServer struct {
+	*grpchealth.Server
+	services []string
+
+	changed chan struct{} //state changes are coalesced,refreshes ping checkers and must not block SetState
+	remove  func()
+
+	changes   atomic.Uint64 //number of state changes seen
+	mu        sync.Mutex
+	refreshed uint64        //number of state changes reflected by the last refresh
+	synced    chan struct{} //closed and replaced after every refresh
+}
+
+// RegisterGrpcServer register grpc.health.v1 service to server if not registered by user,
+// returns nil when already registered
+func RegisterGrpcServer(server *grpc.Server) *GrpcServer {
+	if _, ok := server.GetServiceInfo()[grpc_health_v1.Health_ServiceDesc.ServiceName]; ok {
+		return nil
+	}
+	s := &GrpcServer{Server: grpchealth.NewServer(), changed: make(chan struct{}, 1), synced: make(chan struct{})}
+	for name := range server.GetServiceInfo() {
+		s.services = append(s.services, name)
+	}
+	grpc_health_v1.RegisterHealthServer(server, s)
+	//listen before Run started,states changed in between are not missed
+	s.remove = OnStateChange(func() {
+		s.changes.Add(1)
+		select {
+		case s.changed <- struct{}{}:
+		default:
+		}
+	})
+	return s
+}
+
+// Run refresh statuses on state changes and every GrpcCheckInterval until ctx done
+func (s *GrpcServer) Run(ctx context.Context) {
+	defer s.remove()
+	ticker := time.NewTicker(GrpcCheckInterval)
+	defer ticker.Stop()
+	for {
+		seq := s.changes.Load()
+		s.refresh(ctx)
+		s.mu.Lock()
+		s.refreshed = seq
+		close(s.synced)
+		s.synced = make(chan struct{})
+		s.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.changed:
+		}
+	}
+}
+
+// Sync wait until statuses reflect the state changes happened before the call,Run must be running
+func (s *GrpcServer) Sync(ctx context.Context) error {
+	seq := s.changes.Load()
+	for {
+		s.mu.Lock()
+		refreshed, synced := s.refreshed, s.synced
+		s.mu.Unlock()
+		if refreshed >= seq {
+			return nil
+		}
+		select {
+		case <-synced:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *GrpcServer) refresh(ctx context.Context) {
+	report := Readiness(ctx)
+	overall := toServingStatus(report.Status)
+	s.SetServingStatus("", overall)
+	for _, name := range s.services {
+		s.SetServingStatus(name, overall)
+	}
+	for name, cr := range report.Components {
+		s.SetServingStatus(name, toServingStatus(cr.Status))
+	}
+}
+
+func toServingStatus(status Status) grpc_health_v1.HealthCheckResponse_ServingStatus {
+	if status == StatusUp {
+		return grpc_health_v1.HealthCheckResponse_SERVING
+	}
+	return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+}
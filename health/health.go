@@ -0,0 +1,299 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusUp   Status = "UP"
+	StatusDown Status = "DOWN"
+)
+
+// State lifecycle state of a component,reported by application for each plugin
+type State string
+
+const (
+	StateInitialized State = "initialized"
+	StateRunning     State = "running"
+	StateRestarting  State = "restarting"
+	StateStopping    State = "stopping"
+	StateStopped     State = "stopped"
+	StateFailed      State = "failed"
+	// StateExited run returned an error ignored by supervision,the component does not fail readiness
+	StateExited State = "exited"
+)
+
+// CheckTimeout max duration of a single checker
+var CheckTimeout = 3 * time.Second
+
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapter to allow the use of ordinary functions as Checker
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+type Report struct {
+	Status     Status                      `json:"status"`
+	Components map[string]*ComponentReport `json:"components"`
+}
+
+type ComponentReport struct {
+	Status Status            `json:"status"`
+	State  State             `json:"state,omitempty"`
+	Checks map[string]string `json:"checks,omitempty"`
+
+	Restarts  int    `json:"restarts,omitempty"`
+	LastError string `json:"lastError,omitempty"`
+
+	Details map[string]string `json:"details,omitempty"`
+}
+
+type component struct {
+	state     State
+	checkers  map[string]Checker
+	restarts  int
+	lastError string
+	details   map[string]string
+}
+
+func (c *component) report() *ComponentReport {
+	cr := &ComponentReport{Status: StatusUp, State: c.state, Restarts: c.restarts, LastError: c.lastError}
+	if len(c.details) > 0 {
+		cr.Details = make(map[string]string, len(c.details))
+		for k, v := range c.details {
+			cr.Details[k] = v
+		}
+	}
+	return cr
+}
+
+type Registry struct {
+	components map[string]*component
+	listeners  map[int]func()
+	nextId     int
+	sync.RWMutex
+}
+
+func NewRegistry() *Registry {
+	return &Registry{components: map[string]*component{}, listeners: map[int]func(){}}
+}
+
+func (r *Registry) component(name string) *component {
+	c, ok := r.components[name]
+	if !ok {
+		c = &component{checkers: map[string]Checker{}}
+		r.components[name] = c
+	}
+	return c
+}
+
+// Register register a readiness checker of component,checker with the same name will be replaced
+func (r *Registry) Register(componentName, checkerName string, checker Checker) {
+	r.Lock()
+	defer r.Unlock()
+	r.component(componentName).checkers[checkerName] = checker
+}
+
+func (r *Registry) Unregister(componentName, checkerName string) {
+	r.Lock()
+	defer r.Unlock()
+	if c, ok := r.components[componentName]; ok {
+		delete(c.checkers, checkerName)
+	}
+}
+
+// Reset remove all components with their states and checkers,state change listeners are kept
+func (r *Registry) Reset() {
+	r.Lock()
+	defer r.Unlock()
+	r.components = map[string]*component{}
+}
+
+// SetState set lifecycle state of component,listeners are called synchronously when state changed
+func (r *Registry) SetState(componentName string, state State) {
+	r.Lock()
+	c := r.component(componentName)
+	changed := c.state != state
+	c.state = state
+	listeners := make([]func(), 0, len(r.listeners))
+	for _, fn := range r.listeners {
+		listeners = append(listeners, fn)
+	}
+	r.Unlock()
+	if !changed {
+		return
+	}
+	for _, fn := range listeners {
+		fn()
+	}
+}
+
+// OnStateChange fn is called after state of any component changed,call remove to stop listening
+func (r *Registry) OnStateChange(fn func()) (remove func()) {
+	r.Lock()
+	defer r.Unlock()
+	id := r.nextId
+	r.nextId++
+	r.listeners[id] = fn
+	return func() {
+		r.Lock()
+		defer r.Unlock()
+		delete(r.listeners, id)
+	}
+}
+
+// SetRestarts record restart count of component and the error caused the last restart
+func (r *Registry) SetRestarts(componentName string, restarts int, err error) {
+	r.Lock()
+	defer r.Unlock()
+	c := r.component(componentName)
+	c.restarts = restarts
+	if err != nil {
+		c.lastError = err.Error()
+	}
+}
+
+// SetDetail set informational detail of component,such as staleness of cached data.
+// Empty value removes the detail
+func (r *Registry) SetDetail(componentName, key, value string) {
+	r.Lock()
+	defer r.Unlock()
+	c := r.component(componentName)
+	if value == "" {
+		delete(c.details, key)
+		return
+	}
+	if c.details == nil {
+		c.details = map[string]string{}
+	}
+	c.details[key] = value
+}
+
+// Components names of all known components
+func (r *Registry) Components() []string {
+	r.RLock()
+	defer r.RUnlock()
+	names := make([]string, 0, len(r.components))
+	for name := range r.components {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Liveness report DOWN only when a component failed,checkers are not run
+func (r *Registry) Liveness(_ context.Context) *Report {
+	r.RLock()
+	defer r.RUnlock()
+	report := &Report{Status: StatusUp, Components: make(map[string]*ComponentReport, len(r.components))}
+	for name, c := range r.components {
+		cr := c.report()
+		if c.state == StateFailed {
+			cr.Status = StatusDown
+			report.Status = StatusDown
+		}
+		report.Components[name] = cr
+	}
+	return report
+}
+
+// Readiness report UP when all components with state are running or exited and all checkers passed
+func (r *Registry) Readiness(ctx context.Context) *Report {
+	type result struct {
+		component string
+		checker   string
+		err       error
+	}
+	r.RLock()
+	report := &Report{Status: StatusUp, Components: make(map[string]*ComponentReport, len(r.components))}
+	results := make(chan result)
+	total := 0
+	for name, c := range r.components {
+		cr := c.report()
+		if c.state != "" && c.state != StateRunning && c.state != StateExited {
+			cr.Status = StatusDown
+		}
+		report.Components[name] = cr
+		for checkerName, checker := range c.checkers {
+			total++
+			go func(componentName, checkerName string, checker Checker) {
+				checkCtx, cancel := context.WithTimeout(ctx, CheckTimeout)
+				defer cancel()
+				results <- result{component: componentName, checker: checkerName, err: checker.Check(checkCtx)}
+			}(name, checkerName, checker)
+		}
+	}
+	r.RUnlock()
+
+	for i := 0; i < total; i++ {
+		res := <-results
+		cr := report.Components[res.component]
+		if cr.Checks == nil {
+			cr.Checks = map[string]string{}
+		}
+		if res.err != nil {
+			cr.Checks[res.checker] = res.err.Error()
+			cr.Status = StatusDown
+		} else {
+			cr.Checks[res.checker] = "ok"
+		}
+	}
+	for _, cr := range report.Components {
+		if cr.Status == StatusDown {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+var defaultRegistry = NewRegistry()
+
+// Register register a readiness checker of component to default registry,component is usually a plugin name
+func Register(componentName, checkerName string, checker Checker) {
+	defaultRegistry.Register(componentName, checkerName, checker)
+}
+
+func Unregister(componentName, checkerName string) {
+	defaultRegistry.Unregister(componentName, checkerName)
+}
+
+// Reset remove all components of default registry,tests use it to isolate apps
+func Reset() {
+	defaultRegistry.Reset()
+}
+
+func SetState(componentName string, state State) {
+	defaultRegistry.SetState(componentName, state)
+}
+
+func OnStateChange(fn func()) (remove func()) {
+	return defaultRegistry.OnStateChange(fn)
+}
+
+func SetRestarts(componentName string, restarts int, err error) {
+	defaultRegistry.SetRestarts(componentName, restarts, err)
+}
+
+func SetDetail(componentName, key, value string) {
+	defaultRegistry.SetDetail(componentName, key, value)
+}
+
+func Components() []string {
+	return defaultRegistry.Components()
+}
+
+func Liveness(ctx context.Context) *Report {
+	return defaultRegistry.Liveness(ctx)
+}
+
+func Readiness(ctx context.Context) *Report {
+	return defaultRegistry.Readiness(ctx)
+}
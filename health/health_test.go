@@ -0,0 +1,217 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/no-mole/neptune/json"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	r := NewRegistry()
+	r.SetState("a", StateRunning)
+	r.SetState("b", StateInitialized)
+	r.Register("c", "ping", CheckerFunc(func(ctx context.Context) error { return nil }))
+
+	readiness := r.Readiness(ctx)
+	if readiness.Status != StatusDown || readiness.Components["b"].Status != StatusDown {
+		t.Fatalf("component not running should be DOWN,got %+v", readiness)
+	}
+	if readiness.Components["a"].Status != StatusUp || readiness.Components["c"].Checks["ping"] != "ok" {
+		t.Fatalf("running component and passed checker should be UP,got %+v", readiness)
+	}
+	if r.Liveness(ctx).Status != StatusUp {
+		t.Fatal("liveness should be UP without failed component")
+	}
+
+	r.SetState("b", StateRunning)
+	r.SetState("d", StateExited)
+	if status := r.Readiness(ctx).Status; status != StatusUp {
+		t.Fatalf("all components running or exited should be UP,got %s", status)
+	}
+
+	r.Register("c", "ping", CheckerFunc(func(ctx context.Context) error { return errors.New("unreachable") }))
+	readiness = r.Readiness(ctx)
+	if readiness.Status != StatusDown || readiness.Components["c"].Checks["ping"] != "unreachable" {
+		t.Fatalf("failed checker should be DOWN,got %+v", readiness.Components["c"])
+	}
+	if r.Liveness(ctx).Status != StatusUp {
+		t.Fatal("checkers should not affect liveness")
+	}
+	r.Unregister("c", "ping")
+
+	r.SetState("a", StateFailed)
+	liveness := r.Liveness(ctx)
+	if liveness.Status != StatusDown || liveness.Components["a"].Status != StatusDown {
+		t.Fatalf("failed component should be DOWN,got %+v", liveness)
+	}
+	r.Reset()
+	if components := r.Components(); len(components) != 0 {
+		t.Fatalf("reset should remove all components,got %v", components)
+	}
+}
+
+func TestOnStateChange(t *testing.T) {
+	r := NewRegistry()
+	calls := 0
+	remove := r.OnStateChange(func() { calls++ })
+	r.SetState("a", StateRunning)
+	r.SetState("a", StateRunning)
+	if calls != 1 {
+		t.Fatalf("listener should be called once per change,got %d", calls)
+	}
+	remove()
+	r.SetState("a", StateStopped)
+	if calls != 1 {
+		t.Fatalf("removed listener should not be called,got %d", calls)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	defer func(r *Registry) { defaultRegistry = r }(defaultRegistry)
+	defaultRegistry = NewRegistry()
+	defaultRegistry.SetState("a", StateInitialized)
+
+	server := httptest.NewServer(Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})))
+	defer server.Close()
+	get := func(path string) (int, *Report) {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		report := &Report{}
+		if resp.Header.Get("Content-Type") == "application/json; charset=utf-8" {
+			body, _ := io.ReadAll(resp.Body)
+			if err = json.Unmarshal(body, report); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return resp.StatusCode, report
+	}
+
+	if code, report := get(LivenessPath); code != http.StatusOK || report.Status != StatusUp {
+		t.Fatalf("liveness should be 200 UP,got %d %s", code, report.Status)
+	}
+	if code, report := get(ReadinessPath); code != http.StatusServiceUnavailable || report.Components["a"].State != StateInitialized {
+		t.Fatalf("readiness should be 503 with state of component,got %d %+v", code, report.Components["a"])
+	}
+	defaultRegistry.SetState("a", StateRunning)
+	if code, _ := get(ReadinessPath); code != http.StatusOK {
+		t.Fatalf("readiness should be 200 when running,got %d", code)
+	}
+	defaultRegistry.SetState("a", StateFailed)
+	if code, _ := get(LivenessPath); code != http.StatusServiceUnavailable {
+		t.Fatalf("liveness should be 503 when failed,got %d", code)
+	}
+	if code, _ := get("/ping"); code != http.StatusTeapot {
+		t.Fatalf("other paths should be passed to next,got %d", code)
+	}
+}
+
+func TestGrpcServer(t *testing.T) {
+	defer func(r *Registry) { defaultRegistry = r }(defaultRegistry)
+	defaultRegistry = NewRegistry()
+	defaultRegistry.SetState("grpc-server", StateInitialized)
+	defaultRegistry.SetState("other", StateRunning)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := grpc.NewServer()
+	hs := RegisterGrpcServer(server)
+	if RegisterGrpcServer(server) != nil {
+		t.Fatal("health service should be registered once")
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+	//Run reads the default registry,wait it returned before the registry restored
+	runCtx, stopRun := context.WithCancel(ctx)
+	runDone := make(chan struct{})
+	go func() {
+		hs.Run(runCtx)
+		close(runDone)
+	}()
+	defer func() {
+		stopRun()
+		<-runDone
+	}()
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := grpc_health_v1.NewHealthClient(conn)
+	check := func(service string) grpc_health_v1.HealthCheckResponse_ServingStatus {
+		resp, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Status
+	}
+	sync := func() {
+		if err := hs.Sync(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	//state changes are pushed,no need to wait for GrpcCheckInterval
+	defaultRegistry.SetState("grpc-server", StateStopping)
+	sync()
+	if status := check(""); status != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("overall should be NOT_SERVING,got %s", status)
+	}
+	if status := check("other"); status != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Fatalf("running component should be SERVING,got %s", status)
+	}
+	defaultRegistry.SetState("grpc-server", StateRunning)
+	sync()
+	if status := check(""); status != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Fatalf("overall should be SERVING once all running,got %s", status)
+	}
+	if status := check("grpc-server"); status != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Fatalf("component should be SERVING,got %s", status)
+	}
+
+	//refreshes ping checkers in Run,state changes do not wait on them
+	release := make(chan struct{})
+	defaultRegistry.Register("database", "ping", CheckerFunc(func(ctx context.Context) error {
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return nil
+	}))
+	start := time.Now()
+	defaultRegistry.SetState("grpc-server", StateStopping)
+	defaultRegistry.SetState("grpc-server", StateRunning)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("SetState should not block on slow checkers,took %s", elapsed)
+	}
+	close(release)
+	sync()
+	if status := check("database"); status != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Fatalf("passed checker should be SERVING,got %s", status)
+	}
+
+	hs.Shutdown()
+	if status := check(""); status != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("shutdown should report NOT_SERVING,got %s", status)
+	}
+}
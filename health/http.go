@@ -0,0 +1,55 @@
+package health
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/no-mole/neptune/json"
+)
+
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+)
+
+// LivenessHandler serve liveness report of default registry,respond 503 when DOWN
+func LivenessHandler() http.Handler {
+	return reportHandler(Liveness)
+}
+
+// ReadinessHandler serve readiness report of default registry,respond 503 when DOWN
+func ReadinessHandler() http.Handler {
+	return reportHandler(Readiness)
+}
+
+// Handler serve LivenessPath and ReadinessPath,other requests are passed to next
+func Handler(next http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(LivenessPath, LivenessHandler())
+	mux.Handle(ReadinessPath, ReadinessHandler())
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == LivenessPath || r.URL.Path == ReadinessPath {
+			mux.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func reportHandler(fn func(ctx context.Context) *Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := fn(r.Context())
+		body, err := json.Marshal(report)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if report.Status != StatusUp {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+		_, _ = w.Write(body)
+	})
+}
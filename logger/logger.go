@@ -10,6 +10,7 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"os"
+	"sync"
 )
 
 func init() {
@@ -24,6 +25,11 @@ func SetLogger(l Logger) {
 	defaultLogger = l
 }
 
+// GetLogger global logger
+func GetLogger() Logger {
+	return defaultLogger
+}
+
 // SetLoggerBridge set a warped logger for opentelemetry
 func SetLoggerBridge() {
 	// anyway, global.GetLoggerProvider will return a provider.
@@ -81,6 +87,11 @@ func Trace(ctx context.Context, msg string, fields ...zap.Field) {
 	defaultLogger.Trace(ctx, msg, fields...)
 }
 
+// SetLevel change level of global logger at runtime
+func SetLevel(level Level) {
+	defaultLogger.SetLevel(level)
+}
+
 func AddHandle(ctx context.Context, handle Handle) {
 	defaultLogger.Handle(ctx, handle)
 }
@@ -90,7 +101,8 @@ type logger struct {
 	ctx context.Context
 
 	//level only log < Level.Code()'s Entry
-	level Level
+	level   Level
+	levelMu sync.RWMutex
 
 	//handlers append field to entry
 	handlers []Handle
@@ -103,9 +115,17 @@ func (l *logger) Handle(_ context.Context, handle Handle) {
 }
 
 func (l *logger) SetLevel(level Level) {
+	l.levelMu.Lock()
+	defer l.levelMu.Unlock()
 	l.level = level
 }
 
+func (l *logger) GetLevel() Level {
+	l.levelMu.RLock()
+	defer l.levelMu.RUnlock()
+	return l.level
+}
+
 func (l *logger) Fatal(ctx context.Context, msg string, err error, fields ...zap.Field) {
 	l.logger(ctx, LevelFatal, msg, err, fields...)
 }
@@ -135,7 +155,7 @@ func (l *logger) Trace(ctx context.Context, msg string, fields ...zap.Field) {
 }
 
 func (l *logger) logger(ctx context.Context, curLevel Level, msg string, err error, fields ...zap.Field) {
-	if curLevel.Code() > l.level.Code() {
+	if curLevel.Code() > l.GetLevel().Code() {
 		return
 	}
 	span := trace.SpanFromContext(ctx)
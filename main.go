@@ -3,8 +3,10 @@ package main
 import (
 	"os"
 
+	"github.com/no-mole/neptune/cmd/config"
 	"github.com/no-mole/neptune/cmd/create"
 	"github.com/no-mole/neptune/cmd/protoc"
+	"github.com/no-mole/neptune/cmd/registry"
 	"github.com/spf13/cobra"
 )
 
@@ -18,6 +20,8 @@ var rootCmd = &cobra.Command{
 func main() {
 	rootCmd.AddCommand(create.Command)
 	rootCmd.AddCommand(protoc.Command)
+	rootCmd.AddCommand(config.Command)
+	rootCmd.AddCommand(registry.Command)
 	err := rootCmd.Execute()
 	if err != nil {
 		os.Exit(1)
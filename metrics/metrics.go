@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const Namespace = "neptune"
+
+var (
+	Registerer prometheus.Registerer = prometheus.DefaultRegisterer
+	Gatherer   prometheus.Gatherer   = prometheus.DefaultGatherer
+)
+
+var (
+	GrpcServerHandled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "grpc_server",
+		Name:      "handled_total",
+		Help:      "Total number of RPCs completed on the server.",
+	}, []string{"grpc_type", "grpc_service", "grpc_method", "grpc_code"})
+
+	GrpcServerHandlingSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "grpc_server",
+		Name:      "handling_seconds",
+		Help:      "Histogram of RPC handling latency on the server.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"grpc_type", "grpc_service", "grpc_method"})
+
+	GrpcClientHandled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "grpc_client",
+		Name:      "handled_total",
+		Help:      "Total number of RPCs completed by the client.",
+	}, []string{"grpc_type", "grpc_service", "grpc_method", "grpc_code"})
+
+	GrpcClientHandlingSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "grpc_client",
+		Name:      "handling_seconds",
+		Help:      "Histogram of RPC latency seen by the client.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"grpc_type", "grpc_service", "grpc_method"})
+
+	HttpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "http_server",
+		Name:      "requests_total",
+		Help:      "Total number of http requests completed on the server.",
+	}, []string{"method", "route", "code"})
+
+	HttpRequestSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "http_server",
+		Name:      "request_seconds",
+		Help:      "Histogram of http request latency on the server.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	//CacheRequests status is one of hit,source,shared
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "cache",
+		Name:      "requests_total",
+		Help:      "Total number of requests served by GinCache by cache status.",
+	}, []string{"route", "status"})
+
+	RateLimitRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "ratelimit",
+		Name:      "rejected_total",
+		Help:      "Total number of requests rejected by rate limiter.",
+	}, []string{"route"})
+
+	DbQuerySeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "database",
+		Name:      "query_seconds",
+		Help:      "Histogram of database statement latency.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"db", "operation", "table"})
+
+	DbQueryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "database",
+		Name:      "query_errors_total",
+		Help:      "Total number of failed database statements.",
+	}, []string{"db", "operation", "table"})
+)
+
+func init() {
+	Registerer.MustRegister(
+		GrpcServerHandled,
+		GrpcServerHandlingSeconds,
+		GrpcClientHandled,
+		GrpcClientHandlingSeconds,
+		HttpRequests,
+		HttpRequestSeconds,
+		CacheRequests,
+		RateLimitRejected,
+		DbQuerySeconds,
+		DbQueryErrors,
+	)
+}
+
+// Handler serve metrics of Gatherer in prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Gatherer, promhttp.HandlerOpts{})
+}
+
+var (
+	dbStats   = map[string]prometheus.Collector{}
+	dbStatsMu sync.Mutex
+)
+
+// RegisterDBStats collect connection pool stats of db,registering same name again replaces the old one
+func RegisterDBStats(name string, db *sql.DB) error {
+	dbStatsMu.Lock()
+	defer dbStatsMu.Unlock()
+	if old, ok := dbStats[name]; ok {
+		Registerer.Unregister(old)
+	}
+	collector := collectors.NewDBStatsCollector(db, name)
+	err := Registerer.Register(collector)
+	if err != nil {
+		delete(dbStats, name)
+		return err
+	}
+	dbStats[name] = collector
+	return nil
+}
@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/no-mole/neptune/application"
+	"github.com/no-mole/neptune/logger"
+)
+
+// NewPlugin metrics plugin serving prometheus metrics on a separate endpoint
+func NewPlugin() *Plugin {
+	conf := &Conf{}
+	return &Plugin{
+		ConfigPlugin: application.NewConfigPlugin("metrics", &application.PluginConfigOptions{
+			ConfigName: "app.yaml",
+			ConfigType: "yaml",
+		}, conf),
+		conf: conf,
+	}
+}
+
+type Plugin struct {
+	*application.ConfigPlugin[Conf] `yaml:"-" json:"-"`
+
+	conf     *Conf
+	server   *http.Server
+	listener net.Listener
+}
+
+type Conf struct {
+	Endpoint string `json:"metrics-endpoint" yaml:"metrics-endpoint" flag:"metrics-endpoint" default:"0.0.0.0:9090" usage:"metrics server endpoint,default is [0.0.0.0:9090]" validate:"required"`
+	Path     string `json:"metrics-path" yaml:"metrics-path" flag:"metrics-path" default:"/metrics" usage:"path serving metrics,default is [/metrics]" validate:"required"`
+}
+
+func (p *Plugin) Init(ctx context.Context) (err error) {
+	logger.Info(ctx, "metrics server init", logger.WithField("metricsEndpoint", p.conf.Endpoint))
+	p.listener, err = net.Listen("tcp", p.conf.Endpoint)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle(p.conf.Path, Handler())
+	p.server = &http.Server{Handler: mux}
+	return nil
+}
+
+func (p *Plugin) Run(ctx context.Context) error {
+	logger.Info(ctx, "metrics server started", logger.WithField("metricsEndpoint", p.listener.Addr().String()))
+	err := p.server.Serve(p.listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Stop shutdown metrics server
+func (p *Plugin) Stop(ctx context.Context) error {
+	if p.server == nil {
+		return nil
+	}
+	return p.server.Shutdown(ctx)
+}
+
+// Addr listening address,nil before Init
+func (p *Plugin) Addr() net.Addr {
+	if p.listener == nil {
+		return nil
+	}
+	return p.listener.Addr()
+}
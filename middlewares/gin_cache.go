@@ -15,6 +15,7 @@ import (
 	"github.com/no-mole/neptune/enum"
 	"github.com/no-mole/neptune/json"
 	"github.com/no-mole/neptune/logger"
+	"github.com/no-mole/neptune/metrics"
 	"github.com/no-mole/neptune/output"
 	"golang.org/x/sync/singleflight"
 )
@@ -64,6 +65,7 @@ func GinCache(store cache.Cache, expire time.Duration) gin.HandlerFunc {
 			//走缓存不回源，先Abort
 			if err == nil && len(cachedResponse.([]byte)) > 0 {
 				ctx.Header(CacheStatusKey, CacheStatusHit)
+				metrics.CacheRequests.WithLabelValues(routeLabel(ctx), "hit").Inc()
 				ctx.Abort()
 				body := &cacheBody{}
 				err = json.Unmarshal(cachedResponse.([]byte), body)
@@ -108,6 +110,7 @@ func GinCache(store cache.Cache, expire time.Duration) gin.HandlerFunc {
 		ctx.Writer = writer.ResponseWriter
 		//只缓存200的结果
 		if !shared {
+			metrics.CacheRequests.WithLabelValues(routeLabel(ctx), "source").Inc()
 			if !ctx.IsAborted() && writer.Status() == 200 {
 				go func() {
 					//使用新context避免cancel
@@ -121,6 +124,7 @@ func GinCache(store cache.Cache, expire time.Duration) gin.HandlerFunc {
 		//shared 的需要写入数据并且Abort
 		ctx.Abort()
 		ctx.Header(CacheStatusKey, CacheStatusShared)
+		metrics.CacheRequests.WithLabelValues(routeLabel(ctx), "shared").Inc()
 		err := writeData(ctx, resp.(*cacheBody))
 		if err != nil {
 			outputErr(ctx, &enum.ErrorNumEntry{
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/no-mole/neptune/metrics"
 	"net/http"
 	"sync"
 	"time"
@@ -11,6 +12,7 @@ func GinLimitRateWithBucket(interval time.Duration, cap int64) gin.HandlerFunc {
 	bucket := newBucket(interval, cap)
 	return func(ctx *gin.Context) {
 		if bucket.TakeAvailable(1) < 1 {
+			metrics.RateLimitRejected.WithLabelValues(routeLabel(ctx)).Inc()
 			ctx.String(http.StatusForbidden, "rate limit")
 			ctx.Abort()
 			return
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/no-mole/neptune/metrics"
+)
+
+// GinMetrics record count and latency of requests by route,unmatched requests share one route label
+func GinMetrics() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+		route := routeLabel(ctx)
+		metrics.HttpRequests.WithLabelValues(ctx.Request.Method, route, strconv.Itoa(ctx.Writer.Status())).Inc()
+		metrics.HttpRequestSeconds.WithLabelValues(ctx.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+func routeLabel(ctx *gin.Context) string {
+	route := ctx.FullPath()
+	if route == "" {
+		return "unmatched"
+	}
+	return route
+}
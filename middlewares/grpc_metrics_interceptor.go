@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/no-mole/neptune/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	grpcTypeUnary        = "unary"
+	grpcTypeClientStream = "client_stream"
+	grpcTypeServerStream = "server_stream"
+	grpcTypeBidiStream   = "bidi_stream"
+)
+
+func MetricsGrpcUnaryServerInterceptor() grpc.ServerOption {
+	return grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		observe(metrics.GrpcServerHandled, metrics.GrpcServerHandlingSeconds, grpcTypeUnary, info.FullMethod, start, err)
+		return resp, err
+	})
+}
+
+func MetricsGrpcStreamServerInterceptor() grpc.ServerOption {
+	return grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		observe(metrics.GrpcServerHandled, metrics.GrpcServerHandlingSeconds, streamType(info.IsClientStream, info.IsServerStream), info.FullMethod, start, err)
+		return err
+	})
+}
+
+func MetricsGrpcUnaryClientInterceptor() grpc.DialOption {
+	return grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		observe(metrics.GrpcClientHandled, metrics.GrpcClientHandlingSeconds, grpcTypeUnary, method, start, err)
+		return err
+	})
+}
+
+// MetricsGrpcStreamClientInterceptor observe the time to establish the stream
+func MetricsGrpcStreamClientInterceptor() grpc.DialOption {
+	return grpc.WithChainStreamInterceptor(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		observe(metrics.GrpcClientHandled, metrics.GrpcClientHandlingSeconds, streamType(desc.ClientStreams, desc.ServerStreams), method, start, err)
+		return stream, err
+	})
+}
+
+func observe(counter *prometheus.CounterVec, histogram *prometheus.HistogramVec, grpcType, fullMethod string, start time.Time, err error) {
+	service, method := splitMethodName(fullMethod)
+	counter.WithLabelValues(grpcType, service, method, status.Code(err).String()).Inc()
+	histogram.WithLabelValues(grpcType, service, method).Observe(time.Since(start).Seconds())
+}
+
+// splitMethodName /package.service/method => package.service,method
+func splitMethodName(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.Index(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", fullMethod
+}
+
+func streamType(clientStream, serverStream bool) string {
+	switch {
+	case clientStream && serverStream:
+		return grpcTypeBidiStream
+	case clientStream:
+		return grpcTypeClientStream
+	case serverStream:
+		return grpcTypeServerStream
+	}
+	return grpcTypeUnary
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/no-mole/neptune/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestMetrics(t *testing.T) {
+	//metrics are global,reset them so the test can be repeated
+	metrics.GrpcServerHandled.Reset()
+	metrics.GrpcServerHandlingSeconds.Reset()
+	metrics.GrpcClientHandled.Reset()
+	metrics.GrpcClientHandlingSeconds.Reset()
+	metrics.HttpRequests.Reset()
+	metrics.HttpRequestSeconds.Reset()
+
+	server := grpc.NewServer(MetricsGrpcUnaryServerInterceptor())
+	grpc_health_v1.RegisterHealthServer(server, grpchealth.NewServer())
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+	conn, err := grpc.NewClient(listener.Addr().String(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		MetricsGrpcUnaryClientInterceptor(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_, err = grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(GinMetrics())
+	engine.GET("/users/:id", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, ctx.Param("id"))
+	})
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+	for _, want := range []string{
+		`neptune_grpc_server_handled_total{grpc_code="OK",grpc_method="Check",grpc_service="grpc.health.v1.Health",grpc_type="unary"} 1`,
+		`neptune_grpc_server_handling_seconds_count{grpc_method="Check",grpc_service="grpc.health.v1.Health",grpc_type="unary"} 1`,
+		`neptune_grpc_client_handled_total{grpc_code="OK",grpc_method="Check",grpc_service="grpc.health.v1.Health",grpc_type="unary"} 1`,
+		`neptune_grpc_client_handling_seconds_count{grpc_method="Check",grpc_service="grpc.health.v1.Health",grpc_type="unary"} 1`,
+		`neptune_http_server_requests_total{code="200",method="GET",route="/users/:id"} 1`,
+		`neptune_http_server_request_seconds_count{method="GET",route="/users/:id"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics should contain %s", want)
+		}
+	}
+}
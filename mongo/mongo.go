@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sync"
 	"time"
 
 	"github.com/no-mole/neptune/json"
@@ -15,7 +16,8 @@ import (
 )
 
 var (
-	conns map[string]*BaseMongo
+	conns   map[string]*BaseMongo
+	connsMu sync.RWMutex
 )
 
 func init() {
@@ -41,7 +43,7 @@ type BaseMongo struct {
 }
 
 func (b *BaseMongo) SetClient(engine string) bool {
-	if client, ok := conns[engine]; ok {
+	if client, ok := GetClient(engine); ok {
 		b.DataBase = client.DataBase
 		b.Client = client.Client
 		return true
@@ -49,21 +51,50 @@ func (b *BaseMongo) SetClient(engine string) bool {
 	return false
 }
 
+// GetClient client of mongoName,the returned value is replaced instead of modified on re-init
+func GetClient(mongoName string) (*BaseMongo, bool) {
+	connsMu.RLock()
+	defer connsMu.RUnlock()
+	client, ok := conns[mongoName]
+	return client, ok
+}
+
 func InitMonClient(ctx context.Context, mongoName, strCon string) error {
 	mongoConf := new(MongoConfig)
 	err := json.Unmarshal([]byte(strCon), &mongoConf)
 	if err != nil {
-		panic(err)
+		return err
 	}
+	return InitMonClientWithConfig(ctx, mongoName, mongoConf)
+}
 
-	baseMongo := new(BaseMongo)
-	baseMongo.Client = InitMongoConn(ctx, mongoConf)
-	baseMongo.DataBase = mongoConf.Database
-	conns[mongoName] = baseMongo
+// InitMonClientWithConfig connect and ping,then store the client of mongoName replacing the previous one
+func InitMonClientWithConfig(ctx context.Context, mongoName string, mongoConf *MongoConfig) error {
+	client, err := NewMongoConn(ctx, mongoConf)
+	if err != nil {
+		return err
+	}
+	err = client.Ping(ctx, nil)
+	if err != nil {
+		_ = client.Disconnect(ctx)
+		return err
+	}
+	connsMu.Lock()
+	conns[mongoName] = &BaseMongo{Client: client, DataBase: mongoConf.Database}
+	connsMu.Unlock()
 	return nil
 }
 
+// InitMongoConn panic when failed,use NewMongoConn to handle the error
 func InitMongoConn(ctx context.Context, cfg *MongoConfig) *mongo.Client {
+	client, err := NewMongoConn(ctx, cfg)
+	if err != nil {
+		panic(err)
+	}
+	return client
+}
+
+func NewMongoConn(ctx context.Context, cfg *MongoConfig) (*mongo.Client, error) {
 	credential := options.Credential{
 		Username:   cfg.Username,
 		Password:   cfg.Password,
@@ -79,7 +110,7 @@ func InitMongoConn(ctx context.Context, cfg *MongoConfig) *mongo.Client {
 		SetRegistry(bson.DefaultRegistry).
 		SetMonitor(otelmongo.NewMonitor()))
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	return client
+	return client, nil
 }
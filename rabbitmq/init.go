@@ -1,9 +1,11 @@
 package rabbitmq
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/no-mole/neptune/health"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"golang.org/x/sync/singleflight"
 	"sync"
@@ -11,9 +13,10 @@ import (
 )
 
 var (
-	Client    *client
-	configMap map[string]*Config
-	g         singleflight.Group
+	Client      *client
+	configMap   map[string]*Config
+	configMapMu sync.RWMutex
+	g           singleflight.Group
 )
 
 func init() {
@@ -39,30 +42,44 @@ func InitRabbitMq(rabbitMqName string, confStr string) error {
 	if err != nil {
 		return err
 	}
-	configMap[rabbitMqName] = mqConf
-	conn := getRabbitMqConn(mqConf)
-	Client.StoreClient(rabbitMqName, conn)
-	return nil
+	return InitRabbitMqWithConfig(rabbitMqName, mqConf)
 }
 
+// InitRabbitMqWithConfig dial and store the connection replacing the previous one of rabbitMqName
 func InitRabbitMqWithConfig(rabbitMqName string, conf *Config) error {
+	conn, err := dial(conf)
+	if err != nil {
+		return err
+	}
+	configMapMu.Lock()
 	configMap[rabbitMqName] = conf
-	conn := getRabbitMqConn(conf)
+	configMapMu.Unlock()
 	Client.StoreClient(rabbitMqName, conn)
+	registerHealth(rabbitMqName)
 	return nil
 }
 
-func getRabbitMqConn(mqConf *Config) *amqp.Connection {
+// registerHealth check the stored connection without reconnecting
+func registerHealth(rabbitMqName string) {
+	health.Register("rabbitmq", rabbitMqName, health.CheckerFunc(func(_ context.Context) error {
+		value, ok := Client.Load(rabbitMqName)
+		if !ok {
+			return fmt.Errorf("rabbitmq [%s] not initialized", rabbitMqName)
+		}
+		if conn, ok := value.(*amqp.Connection); !ok || conn.IsClosed() {
+			return fmt.Errorf("rabbitmq [%s] connection closed", rabbitMqName)
+		}
+		return nil
+	}))
+}
+
+func dial(mqConf *Config) (*amqp.Connection, error) {
 	url := fmt.Sprintf("amqp://%s:%s@%s/", mqConf.Username, mqConf.Password, mqConf.Host)
-	conn, err := amqp.DialConfig(url, amqp.Config{
+	return amqp.DialConfig(url, amqp.Config{
 		Vhost:     mqConf.Vhosts,
 		Heartbeat: 10 * time.Second,
 		Locale:    "zh_CN",
 	})
-	if err != nil {
-		panic(err)
-	}
-	return conn
 }
 
 func (c *client) StoreClient(key string, value *amqp.Connection) {
@@ -81,12 +98,18 @@ func (c *client) GetClient(key string) (*amqp.Connection, bool) {
 
 func (c *client) ReConnect(key string) (*amqp.Connection, bool) {
 	cc, _, _ := g.Do(key, func() (interface{}, error) {
-		if conf, ok := configMap[key]; ok {
-			cc := getRabbitMqConn(conf)
-			c.Store(key, cc)
-			return cc, nil
+		configMapMu.RLock()
+		conf, ok := configMap[key]
+		configMapMu.RUnlock()
+		if !ok {
+			return nil, errors.New("not match map")
+		}
+		cc, err := dial(conf)
+		if err != nil {
+			return nil, err
 		}
-		return nil, errors.New("not match map")
+		c.Store(key, cc)
+		return cc, nil
 	})
 	conn, ok := cc.(*amqp.Connection)
 	if !ok {
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"github.com/go-redis/redis/extra/redisotel/v8"
 	"github.com/go-redis/redis/v8"
+	"github.com/no-mole/neptune/health"
 	"go.opentelemetry.io/otel/attribute"
 	"gopkg.in/yaml.v3"
 	"sync"
@@ -49,6 +50,9 @@ func InitWithConfig(redisName string, redisConf *Config) error {
 		),
 	)
 	Client.StoreClient(redisName, curClient)
+	health.Register("redis", redisName, health.CheckerFunc(func(ctx context.Context) error {
+		return curClient.Ping(ctx).Err()
+	}))
 	return nil
 }
 
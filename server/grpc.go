@@ -4,13 +4,14 @@ import (
 	"context"
 	"errors"
 	"net"
+	"sync"
 
 	"github.com/no-mole/neptune/application"
 	"github.com/no-mole/neptune/grpc_service"
+	"github.com/no-mole/neptune/health"
 	"github.com/no-mole/neptune/logger"
 	"github.com/no-mole/neptune/utils"
 	"google.golang.org/grpc"
-	"gopkg.in/yaml.v3"
 )
 
 type GrpcService struct {
@@ -19,49 +20,47 @@ type GrpcService struct {
 }
 
 func NewGrpcServerPlugin(grpcServerFn func(ctx context.Context) *grpc.Server, services ...GrpcService) application.Plugin {
+	conf := &GrpcServerPluginConf{}
 	plg := &GrpcServerPlugin{
-		Plugin: application.NewPluginConfig("grpc-server", &application.PluginConfigOptions{
+		ConfigPlugin: application.NewConfigPlugin("grpc-server", &application.PluginConfigOptions{
 			ConfigName: "app.yaml",
 			ConfigType: "yaml",
 			EnvPrefix:  "",
-		}),
+		}, conf),
 		fn:       grpcServerFn,
 		services: services,
-		err:      make(chan error, 1),
-		conf:     &GrpcServerPluginConf{},
+		ready:    make(chan struct{}),
+		conf:     conf,
 	}
-	plg.Flags().StringVar(&plg.conf.GrpcEndpoint, "grpc-endpoint", "0.0.0.0:8080", "grpc监听地址,默认为 [0.0.0.0:8080]")
-	plg.Flags().StringVar(&plg.conf.ServiceEndpoint, "service-endpoint", "", "服务注册使用的地址，从环境变量中取或者取第一个非回环ip [ip:port]")
 	return plg
 }
 
 type GrpcServerPlugin struct {
-	application.Plugin `yaml:"-"`
+	*application.ConfigPlugin[GrpcServerPluginConf] `yaml:"-"`
 
 	fn       func(ctx context.Context) *grpc.Server
-	server   *grpc.Server `yaml:"-"`
-	listener net.Listener `yaml:"-"`
+	mu       sync.Mutex         `yaml:"-"`
+	server   *grpc.Server       `yaml:"-"`
+	health   *health.GrpcServer `yaml:"-"`
+	listener net.Listener       `yaml:"-"`
 
 	services []GrpcService `yaml:"-"`
 
 	ep string `yaml:"-"`
 
-	err chan error `yaml:"-"`
+	ready     chan struct{} `yaml:"-"`
+	readyOnce sync.Once     `yaml:"-"`
 
 	conf *GrpcServerPluginConf
 }
 
 type GrpcServerPluginConf struct {
-	GrpcEndpoint    string `yaml:"grpc-endpoint" json:"grpc-endpoint"`
-	ServiceEndpoint string `yaml:"service-endpoint" json:"service-endpoint"`
+	GrpcEndpoint    string `yaml:"grpc-endpoint" json:"grpc-endpoint" flag:"grpc-endpoint" default:"0.0.0.0:8080" usage:"grpc监听地址,默认为 [0.0.0.0:8080]" validate:"required"`
+	ServiceEndpoint string `yaml:"service-endpoint" json:"service-endpoint" flag:"service-endpoint" usage:"服务注册使用的地址，从环境变量中取或者取第一个非回环ip [ip:port]"`
 }
 
 var ErrorEmptyEndpoint = errors.New("grpc server plugin used but not initialization")
 
-func (g *GrpcServerPlugin) Config(_ context.Context, conf []byte) error {
-	return yaml.Unmarshal(conf, g.conf)
-}
-
 func (g *GrpcServerPlugin) Init(ctx context.Context) error {
 	ep, err := g.DiscoverTheEntrance()
 	if err != nil {
@@ -85,35 +84,47 @@ func (g *GrpcServerPlugin) Init(ctx context.Context) error {
 	if g.conf.GrpcEndpoint == "" {
 		return ErrorEmptyEndpoint
 	}
-	host, port, err := net.SplitHostPort(g.conf.GrpcEndpoint)
-	if err != nil {
-		return err
-	}
+	_, _, err = net.SplitHostPort(g.conf.GrpcEndpoint)
+	return err
+}
 
-	listener, err := net.Listen("tcp", net.JoinHostPort(host, port))
+// Run listen and serve,a new listener and server are created on every run,so it can be restarted
+func (g *GrpcServerPlugin) Run(ctx context.Context) error {
+	g.mu.Lock()
+	if ctx.Err() != nil {
+		//app stopped before running
+		g.mu.Unlock()
+		return nil
+	}
+	listener, err := net.Listen("tcp", g.conf.GrpcEndpoint)
 	if err != nil {
+		g.mu.Unlock()
 		return err
 	}
 	g.listener = listener
-	return nil
-}
-func (g *GrpcServerPlugin) Run(ctx context.Context) error {
 	g.server = g.fn(ctx)
 	for _, service := range g.services {
 		g.server.RegisterService(service.Metadata.ServiceDesc(), service.Impl)
 	}
+	g.health = health.RegisterGrpcServer(g.server)
+	server := g.server
+	healthServer := g.health
+	g.mu.Unlock()
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if healthServer != nil {
+		go healthServer.Run(runCtx)
+	}
+	serveErr := make(chan error, 1)
 	go func() {
-		g.err <- g.server.Serve(g.listener)
+		serveErr <- server.Serve(listener)
 	}()
-	for _, service := range g.services {
-		err := grpc_service.Register(context.Background(), g.ep, service.Metadata)
-		if err != nil {
-			return err
-		}
+	err = grpc_service.Register(context.Background(), g.ep, g.metadata()...)
+	if err != nil {
+		server.Stop()
+		return err
 	}
-	defer func() {
-		close(g.err)
-	}()
+	g.readyOnce.Do(func() { close(g.ready) })
 	logger.Info(
 		ctx,
 		"grpc server started",
@@ -124,11 +135,82 @@ func (g *GrpcServerPlugin) Run(ctx context.Context) error {
 	select {
 	case <-ctx.Done():
 		return nil
-	case err := <-g.err:
+	case err := <-serveErr:
 		return err
 	}
 }
 
+// OptionalDependencies grpc server maybe use config center in grpcServerFn and register services by grpc register
+func (g *GrpcServerPlugin) OptionalDependencies() []string {
+	return []string{"config-center", "grpc-register"}
+}
+
+// Ready closed after server started serving and all services registered
+func (g *GrpcServerPlugin) Ready() <-chan struct{} {
+	return g.ready
+}
+
+// SyncHealth wait until grpc health statuses reflect the latest plugin states
+func (g *GrpcServerPlugin) SyncHealth(ctx context.Context) error {
+	g.mu.Lock()
+	healthServer := g.health
+	g.mu.Unlock()
+	if healthServer == nil {
+		return nil
+	}
+	return healthServer.Sync(ctx)
+}
+
+// Addr listening address,nil before Run
+func (g *GrpcServerPlugin) Addr() net.Addr {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.listener == nil {
+		return nil
+	}
+	return g.listener.Addr()
+}
+
+// Stop unregister services from discovery first,then graceful stop the server,
+// force stop it when ctx is done before all pending rpc finished
+func (g *GrpcServerPlugin) Stop(ctx context.Context) error {
+	g.mu.Lock()
+	server := g.server
+	healthServer := g.health
+	g.mu.Unlock()
+	if healthServer != nil {
+		//report NOT_SERVING to health checking clients while draining
+		healthServer.Shutdown()
+	}
+	if server == nil {
+		return nil
+	}
+	err := grpc_service.Unregister(ctx, g.ep, g.metadata()...)
+	if err != nil {
+		logger.Error(ctx, "grpc server unregister services", err, logger.WithField("grpcServiceEntrance", g.ep))
+	}
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.Stop()
+		logger.Warning(ctx, "grpc server graceful stop timeout,force stopped", ctx.Err())
+	}
+	return err
+}
+
+func (g *GrpcServerPlugin) metadata() []grpc_service.Metadata {
+	mds := make([]grpc_service.Metadata, 0, len(g.services))
+	for _, service := range g.services {
+		mds = append(mds, service.Metadata)
+	}
+	return mds
+}
+
 func (g *GrpcServerPlugin) DiscoverTheEntrance() (string, error) {
 	if g.conf.ServiceEndpoint == "" {
 		g.conf.ServiceEndpoint = g.conf.GrpcEndpoint
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/no-mole/neptune/grpc_service"
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+var errRegister = errors.New("registry unavailable")
+
+// flakyRegister fails the first registration
+type flakyRegister struct {
+	calls int
+}
+
+func (f *flakyRegister) Register(_ context.Context, _ grpc_service.Metadata, _ string) error {
+	f.calls++
+	if f.calls == 1 {
+		return errRegister
+	}
+	return nil
+}
+
+func (f *flakyRegister) Unregister(_ context.Context, _ grpc_service.Metadata, _ string) error {
+	return nil
+}
+
+func (f *flakyRegister) Close() error { return nil }
+
+func TestGrpcServerPluginRerun(t *testing.T) {
+	grpc_service.SetDefaultRegister(&flakyRegister{})
+	g := NewGrpcServerPlugin(func(ctx context.Context) *grpc.Server { return grpc.NewServer() }, GrpcService{
+		Metadata: grpc_service.NewServiceMetadata(&grpc_health_v1.Health_ServiceDesc, "v1"),
+		Impl:     grpchealth.NewServer(),
+	}).(*GrpcServerPlugin)
+	g.conf.GrpcEndpoint = "127.0.0.1:0"
+	if err := g.Init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	//failed registration stops the server it started
+	if err := g.Run(context.Background()); !errors.Is(err, errRegister) {
+		t.Fatalf("expect register error,got %v", err)
+	}
+	if conn, err := net.Dial("tcp", g.Addr().String()); err == nil {
+		_ = conn.Close()
+		t.Fatal("server should be stopped after register failed")
+	}
+
+	//restarted runs listen again and do not close ready twice
+	prev := g.Addr()
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- g.Run(ctx) }()
+		select {
+		case <-g.Ready():
+		case err := <-done:
+			t.Fatalf("run %d exited before ready: %v", i, err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("run %d not ready", i)
+		}
+		//ready is closed once,wait for the listener of this run
+		deadline := time.Now().Add(5 * time.Second)
+		for g.Addr() == prev && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		prev = g.Addr()
+		conn, err := net.Dial("tcp", prev.String())
+		if err != nil {
+			t.Fatalf("run %d should be listening: %v", i, err)
+		}
+		_ = conn.Close()
+		cancel()
+		if err := <-done; err != nil {
+			t.Fatalf("run %d: %v", i, err)
+		}
+		if err := g.Stop(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
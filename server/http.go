@@ -4,46 +4,43 @@ import (
 	"context"
 	"errors"
 	"github.com/no-mole/neptune/application"
+	"github.com/no-mole/neptune/health"
 	"github.com/no-mole/neptune/logger"
-	"gopkg.in/yaml.v3"
 	"net"
 	"net/http"
 )
 
 func NewHttpServerPlugin(handlerFn func(ctx context.Context) http.Handler) application.Plugin {
+	conf := &HttpServerPluginConf{}
 	plg := &HttpServerPlugin{
-		Plugin: application.NewPluginConfig("http-server", &application.PluginConfigOptions{
+		ConfigPlugin: application.NewConfigPlugin("http-server", &application.PluginConfigOptions{
 			ConfigName: "app.yaml",
 			ConfigType: "yaml",
 			EnvPrefix:  "",
-		}),
+		}, conf),
 		handlerFn: handlerFn,
-		conf:      &HttpServerPluginConf{},
+		conf:      conf,
 	}
-	plg.Flags().StringVar(&plg.conf.Endpoint, "http-endpoint", "0.0.0.0:80", "http server endpoint,default is [0.0.0.0:80]")
 	return plg
 }
 
 type HttpServerPlugin struct {
-	application.Plugin `yaml:"-" json:"-"`
+	*application.ConfigPlugin[HttpServerPluginConf] `yaml:"-" json:"-"`
 
 	handlerFn func(ctx context.Context) http.Handler
-	handler   http.Handler
+	server    *http.Server
 	listener  net.Listener
 
 	conf *HttpServerPluginConf
 }
 
 type HttpServerPluginConf struct {
-	Endpoint string `json:"http-endpoint" yaml:"http-endpoint"`
+	Endpoint string `json:"http-endpoint" yaml:"http-endpoint" flag:"http-endpoint" default:"0.0.0.0:80" usage:"http server endpoint,default is [0.0.0.0:80]" validate:"required"`
+	Health   bool   `json:"http-health" yaml:"http-health" flag:"http-health" default:"true" usage:"serve /healthz and /readyz for probes,default is true"`
 }
 
 var ErrorEmptyHttpEndpoint = errors.New("http server plugin used but not initialization")
 
-func (h *HttpServerPlugin) Config(_ context.Context, conf []byte) error {
-	return yaml.Unmarshal(conf, h.conf)
-}
-
 func (h *HttpServerPlugin) Init(ctx context.Context) error {
 	logger.Info(
 		ctx,
@@ -61,14 +58,48 @@ func (h *HttpServerPlugin) Init(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	h.server = &http.Server{}
 	return nil
 }
 func (h *HttpServerPlugin) Run(ctx context.Context) error {
-	h.handler = h.handlerFn(ctx)
+	h.server.Handler = h.handlerFn(ctx)
+	if h.conf.Health {
+		h.server.Handler = health.Handler(h.server.Handler)
+	}
 	logger.Info(
 		ctx,
 		"http server started",
 		logger.WithField("httpServerEndpoint", h.conf.Endpoint),
 	)
-	return http.Serve(h.listener, h.handler)
+	err := h.server.Serve(h.listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// OptionalDependencies handler maybe use config center
+func (h *HttpServerPlugin) OptionalDependencies() []string {
+	return []string{"config-center"}
+}
+
+// Stop graceful shutdown the server,waiting for active connections until ctx is done
+func (h *HttpServerPlugin) Stop(ctx context.Context) error {
+	if h.server == nil {
+		return nil
+	}
+	return h.server.Shutdown(ctx)
+}
+
+// Restartable the listener is closed when Serve returns,Run can not be called again
+func (h *HttpServerPlugin) Restartable() bool {
+	return false
+}
+
+// Addr listening address,nil before Init
+func (h *HttpServerPlugin) Addr() net.Addr {
+	if h.listener == nil {
+		return nil
+	}
+	return h.listener.Addr()
 }
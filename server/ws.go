@@ -6,27 +6,26 @@ import (
 	"github.com/no-mole/neptune/application"
 	"github.com/no-mole/neptune/logger"
 	"github.com/pkg/errors"
-	"gopkg.in/yaml.v3"
 	"net"
 	"net/http"
 )
 
 func NewWebSocketServerPlugin(handlerFn func(ctx context.Context, w http.ResponseWriter, r *http.Request)) application.Plugin {
+	conf := &WsServerPluginConf{}
 	plg := &WebSocketServerPlugin{
-		Plugin: application.NewPluginConfig("ws-server", &application.PluginConfigOptions{
+		ConfigPlugin: application.NewConfigPlugin("ws-server", &application.PluginConfigOptions{
 			ConfigName: "app.yaml",
 			ConfigType: "yaml",
 			EnvPrefix:  "",
-		}),
+		}, conf),
 		handlerFn: handlerFn,
-		conf:      &WsServerPluginConf{},
+		conf:      conf,
 	}
-	plg.Flags().StringVar(&plg.conf.Endpoint, "ws-endpoint", "0.0.0.0:80", "ws server endpoint,default is [0.0.0.0:80]")
 	return plg
 }
 
 type WebSocketServerPlugin struct {
-	application.Plugin `yaml:"-" json:"-"`
+	*application.ConfigPlugin[WsServerPluginConf] `yaml:"-" json:"-"`
 
 	handlerFn func(ctx context.Context, w http.ResponseWriter, r *http.Request) // WebSocket 处理函数
 	server    *http.Server
@@ -37,15 +36,11 @@ type WebSocketServerPlugin struct {
 }
 
 type WsServerPluginConf struct {
-	Endpoint string `json:"ws-endpoint" yaml:"ws-endpoint"`
+	Endpoint string `json:"ws-endpoint" yaml:"ws-endpoint" flag:"ws-endpoint" default:"0.0.0.0:80" usage:"ws server endpoint,default is [0.0.0.0:80]" validate:"required"`
 }
 
 var ErrorEmptyWsEndpoint = errors.New("ws server plugin used but not initialization")
 
-func (w *WebSocketServerPlugin) Config(_ context.Context, conf []byte) error {
-	return yaml.Unmarshal(conf, w.conf)
-}
-
 func (w *WebSocketServerPlugin) Init(ctx context.Context) error {
 	logger.Info(
 		ctx,
@@ -88,5 +83,35 @@ func (w *WebSocketServerPlugin) Run(ctx context.Context) error {
 		logger.WithField("wsServerEndpoint", w.conf.Endpoint),
 	)
 	// 运行 WebSocket 服务器
-	return w.server.Serve(w.listener)
+	err := w.server.Serve(w.listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// OptionalDependencies handler maybe use config center
+func (w *WebSocketServerPlugin) OptionalDependencies() []string {
+	return []string{"config-center"}
+}
+
+// Stop graceful shutdown the server,hijacked websocket connections are not tracked by http.Server
+func (w *WebSocketServerPlugin) Stop(ctx context.Context) error {
+	if w.server == nil {
+		return nil
+	}
+	return w.server.Shutdown(ctx)
+}
+
+// Restartable the listener is closed when Serve returns,Run can not be called again
+func (w *WebSocketServerPlugin) Restartable() bool {
+	return false
+}
+
+// Addr listening address,nil before Init
+func (w *WebSocketServerPlugin) Addr() net.Addr {
+	if w.listener == nil {
+		return nil
+	}
+	return w.listener.Addr()
 }
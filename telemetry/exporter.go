@@ -0,0 +1,90 @@
+package telemetry
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutlog"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// output writer of stdout and file exporters
+func (p *Plugin) output() io.Writer {
+	if p.writer != nil {
+		return p.writer
+	}
+	return os.Stdout
+}
+
+func (p *Plugin) traceExporter(ctx context.Context) (sdktrace.SpanExporter, error) {
+	if p.conf.Exporter != ExporterOtlp {
+		return stdouttrace.New(stdouttrace.WithWriter(p.output()))
+	}
+	if p.conf.Protocol == ProtocolHttp {
+		opts := []otlptracehttp.Option{otlptracehttp.WithHeaders(p.conf.Headers)}
+		if p.conf.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(p.conf.Endpoint))
+		}
+		if p.conf.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	}
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithHeaders(p.conf.Headers)}
+	if p.conf.Endpoint != "" {
+		opts = append(opts, otlptracegrpc.WithEndpoint(p.conf.Endpoint))
+	}
+	if p.conf.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	return otlptracegrpc.New(ctx, opts...)
+}
+
+func (p *Plugin) metricExporter(ctx context.Context) (sdkmetric.Exporter, error) {
+	if p.conf.Exporter != ExporterOtlp {
+		return stdoutmetric.New(stdoutmetric.WithWriter(p.output()))
+	}
+	if p.conf.Protocol == ProtocolHttp {
+		opts := []otlpmetrichttp.Option{otlpmetrichttp.WithHeaders(p.conf.Headers)}
+		if p.conf.Endpoint != "" {
+			opts = append(opts, otlpmetrichttp.WithEndpoint(p.conf.Endpoint))
+		}
+		if p.conf.Insecure {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		}
+		return otlpmetrichttp.New(ctx, opts...)
+	}
+	opts := []otlpmetricgrpc.Option{otlpmetricgrpc.WithHeaders(p.conf.Headers)}
+	if p.conf.Endpoint != "" {
+		opts = append(opts, otlpmetricgrpc.WithEndpoint(p.conf.Endpoint))
+	}
+	if p.conf.Insecure {
+		opts = append(opts, otlpmetricgrpc.WithInsecure())
+	}
+	return otlpmetricgrpc.New(ctx, opts...)
+}
+
+// logExporter otlp logs only support http,see exportLogs
+func (p *Plugin) logExporter(ctx context.Context) (sdklog.Exporter, error) {
+	if p.conf.Exporter != ExporterOtlp {
+		return stdoutlog.New(stdoutlog.WithWriter(p.output()))
+	}
+	opts := []otlploghttp.Option{otlploghttp.WithHeaders(p.conf.Headers)}
+	if p.conf.Endpoint != "" {
+		opts = append(opts, otlploghttp.WithEndpoint(p.conf.Endpoint))
+	}
+	if p.conf.Insecure {
+		opts = append(opts, otlploghttp.WithInsecure())
+	}
+	return otlploghttp.New(ctx, opts...)
+}
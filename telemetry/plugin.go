@@ -0,0 +1,204 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/no-mole/neptune/application"
+	"github.com/no-mole/neptune/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/log/global"
+	"go.opentelemetry.io/otel/propagation"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+const (
+	ExporterOtlp   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+	ExporterNone   = "none"
+
+	ProtocolGrpc = "grpc"
+	ProtocolHttp = "http"
+)
+
+// NewPlugin telemetry plugin configure global opentelemetry providers,
+// should be used before other plugins so that they are initialized with configured providers
+func NewPlugin(app *application.App) *Plugin {
+	conf := &Conf{}
+	return &Plugin{
+		ConfigPlugin: application.NewConfigPlugin("telemetry", &application.PluginConfigOptions{
+			ConfigName: "app.yaml",
+			ConfigType: "yaml",
+		}, conf),
+		app:  app,
+		conf: conf,
+	}
+}
+
+type Plugin struct {
+	*application.ConfigPlugin[Conf] `yaml:"-" json:"-"`
+
+	app  *application.App
+	conf *Conf
+
+	writer         io.WriteCloser
+	tracerProvider *sdktrace.TracerProvider
+	meterProvider  *sdkmetric.MeterProvider
+	loggerProvider *sdklog.LoggerProvider
+}
+
+type Conf struct {
+	ServiceName    string            `json:"telemetry-service-name" yaml:"telemetry-service-name" flag:"telemetry-service-name" usage:"service.name resource attribute,default is the executable name"`
+	ServiceVersion string            `json:"telemetry-service-version" yaml:"telemetry-service-version" flag:"telemetry-service-version" usage:"service.version resource attribute"`
+	Attributes     map[string]string `json:"telemetry-attributes" yaml:"telemetry-attributes" flag:"telemetry-attributes" usage:"extra resource attributes,k1=v1,k2=v2"`
+
+	Exporter string            `json:"telemetry-exporter" yaml:"telemetry-exporter" flag:"telemetry-exporter" default:"otlp" usage:"exporter for [otlp|stdout|file|none],default is otlp" validate:"oneof=otlp stdout file none"`
+	Protocol string            `json:"telemetry-protocol" yaml:"telemetry-protocol" flag:"telemetry-protocol" default:"http" usage:"otlp protocol for [http|grpc],logs are not exported with grpc,default is http" validate:"oneof=grpc http"`
+	Endpoint string            `json:"telemetry-endpoint" yaml:"telemetry-endpoint" flag:"telemetry-endpoint" usage:"otlp collector endpoint host:port,default is OTEL_EXPORTER_OTLP_ENDPOINT or localhost"`
+	Insecure bool              `json:"telemetry-insecure" yaml:"telemetry-insecure" flag:"telemetry-insecure" default:"true" usage:"disable otlp client transport security,default is true"`
+	Headers  map[string]string `json:"telemetry-headers" yaml:"telemetry-headers" flag:"telemetry-headers" usage:"otlp request headers,k1=v1,k2=v2"`
+	File     string            `json:"telemetry-file" yaml:"telemetry-file" flag:"telemetry-file" default:"telemetry.log" usage:"output file of file exporter,default is telemetry.log"`
+
+	Traces         bool          `json:"telemetry-traces" yaml:"telemetry-traces" flag:"telemetry-traces" default:"true" usage:"export traces,default is true"`
+	Metrics        bool          `json:"telemetry-metrics" yaml:"telemetry-metrics" flag:"telemetry-metrics" default:"true" usage:"export metrics,default is true"`
+	Logs           bool          `json:"telemetry-logs" yaml:"telemetry-logs" flag:"telemetry-logs" default:"true" usage:"export logs and bridge logger to opentelemetry,otlp logs need http protocol,default is true"`
+	SampleRatio    float64       `json:"telemetry-sample-ratio" yaml:"telemetry-sample-ratio" flag:"telemetry-sample-ratio" default:"1" usage:"trace sampling ratio of root spans in [0,1],default is 1" validate:"gte=0,lte=1"`
+	MetricInterval time.Duration `json:"telemetry-metric-interval" yaml:"telemetry-metric-interval" flag:"telemetry-metric-interval" default:"60s" usage:"interval of metrics export,default is 60s" validate:"gt=0"`
+}
+
+// exportLogs otlp log exporter only supports http,logs are not exported with grpc protocol
+func (p *Plugin) exportLogs() bool {
+	return p.conf.Logs && !(p.conf.Exporter == ExporterOtlp && p.conf.Protocol == ProtocolGrpc)
+}
+
+func (p *Plugin) Init(ctx context.Context) (err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if p.conf.Exporter == ExporterNone {
+		return nil
+	}
+	if p.conf.Exporter == ExporterFile {
+		p.writer, err = os.OpenFile(p.conf.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+	}
+	res, err := p.resource(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = p.shutdown(ctx)
+		}
+	}()
+	if p.conf.Traces {
+		exporter, err := p.traceExporter(ctx)
+		if err != nil {
+			return err
+		}
+		p.tracerProvider = sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exporter),
+			sdktrace.WithResource(res),
+			sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(p.conf.SampleRatio))),
+		)
+		otel.SetTracerProvider(p.tracerProvider)
+	}
+	if p.conf.Metrics {
+		exporter, err := p.metricExporter(ctx)
+		if err != nil {
+			return err
+		}
+		p.meterProvider = sdkmetric.NewMeterProvider(
+			sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(p.conf.MetricInterval))),
+			sdkmetric.WithResource(res),
+		)
+		otel.SetMeterProvider(p.meterProvider)
+	}
+	if p.conf.Logs && !p.exportLogs() {
+		logger.Warning(ctx, "telemetry logs not exported,otlp grpc log exporter is not supported", nil)
+	}
+	if p.exportLogs() {
+		exporter, err := p.logExporter(ctx)
+		if err != nil {
+			return err
+		}
+		p.loggerProvider = sdklog.NewLoggerProvider(
+			sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter)),
+			sdklog.WithResource(res),
+		)
+		global.SetLoggerProvider(p.loggerProvider)
+		//bridge replaces the global logger,keep the level set by app
+		l, ok := logger.GetLogger().(interface{ GetLevel() logger.Level })
+		logger.SetLoggerBridge()
+		if ok {
+			logger.SetLevel(l.GetLevel())
+		}
+	}
+	logger.Info(ctx, "telemetry init",
+		logger.WithField("exporter", p.conf.Exporter),
+		logger.WithField("protocol", p.conf.Protocol),
+		logger.WithField("endpoint", p.conf.Endpoint),
+	)
+	return nil
+}
+
+func (p *Plugin) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+// Stop flush and shutdown providers
+func (p *Plugin) Stop(ctx context.Context) error {
+	return p.shutdown(ctx)
+}
+
+func (p *Plugin) shutdown(ctx context.Context) error {
+	var errs []error
+	if p.tracerProvider != nil {
+		errs = append(errs, p.tracerProvider.Shutdown(ctx))
+	}
+	if p.meterProvider != nil {
+		errs = append(errs, p.meterProvider.Shutdown(ctx))
+	}
+	if p.loggerProvider != nil {
+		errs = append(errs, p.loggerProvider.Shutdown(ctx))
+	}
+	if p.writer != nil {
+		errs = append(errs, p.writer.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (p *Plugin) resource(ctx context.Context) (*resource.Resource, error) {
+	serviceName := p.conf.ServiceName
+	if serviceName == "" {
+		serviceName = filepath.Base(os.Args[0])
+	}
+	attrs := []attribute.KeyValue{
+		semconv.ServiceName(serviceName),
+		semconv.DeploymentEnvironment(p.app.Mode),
+	}
+	if p.conf.ServiceVersion != "" {
+		attrs = append(attrs, semconv.ServiceVersion(p.conf.ServiceVersion))
+	}
+	for k, v := range p.conf.Attributes {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+	return resource.New(ctx,
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithFromEnv(),
+		resource.WithAttributes(attrs...),
+	)
+}
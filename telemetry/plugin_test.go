@@ -0,0 +1,92 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/no-mole/neptune/application"
+	"github.com/no-mole/neptune/logger"
+	"go.opentelemetry.io/otel"
+)
+
+type nopCloser struct {
+	*bytes.Buffer
+}
+
+func (nopCloser) Close() error { return nil }
+
+func TestValidateConfig(t *testing.T) {
+	p := NewPlugin(application.New(context.Background()))
+	if err := p.ValidateConfig(); err != nil {
+		t.Fatalf("defaults should be valid,got %v", err)
+	}
+	p.conf.Protocol = ProtocolGrpc
+	if err := p.ValidateConfig(); err != nil {
+		t.Fatalf("otlp grpc with default logs should be valid,got %v", err)
+	}
+	if p.exportLogs() {
+		t.Fatal("logs should not be exported with otlp grpc")
+	}
+	p.conf.Protocol = ProtocolHttp
+	if !p.exportLogs() {
+		t.Fatal("logs should be exported with otlp http")
+	}
+	p.conf.Exporter = "zipkin"
+	if err := p.ValidateConfig(); err == nil {
+		t.Fatal("unknown exporter should be rejected")
+	}
+	p.conf.Exporter = ExporterStdout
+	p.conf.SampleRatio = 2
+	if err := p.ValidateConfig(); err == nil {
+		t.Fatal("sample ratio out of [0,1] should be rejected")
+	}
+}
+
+func TestProviders(t *testing.T) {
+	defer func(l logger.Logger) { logger.SetLogger(l) }(logger.GetLogger())
+	ctx := context.Background()
+	build := func(exporter string, init func(p *Plugin)) {
+		p := NewPlugin(application.New(ctx))
+		p.conf.Exporter = exporter
+		p.conf.ServiceName = "telemetry-test"
+		init(p)
+		if err := p.ValidateConfig(); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Init(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if p.tracerProvider == nil || p.meterProvider == nil || p.loggerProvider == nil {
+			t.Fatalf("%s exporter should build all providers", exporter)
+		}
+		_, span := otel.Tracer("test").Start(ctx, exporter+"-span")
+		span.End()
+		if err := p.Stop(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := &bytes.Buffer{}
+	build(ExporterStdout, func(p *Plugin) {
+		p.writer = nopCloser{Buffer: out}
+	})
+	if !strings.Contains(out.String(), "stdout-span") || !strings.Contains(out.String(), "telemetry-test") {
+		t.Fatalf("stdout exporter should write spans with resource,got %s", out.String())
+	}
+
+	file := filepath.Join(t.TempDir(), "telemetry.log")
+	build(ExporterFile, func(p *Plugin) {
+		p.conf.File = file
+	})
+	body, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), "file-span") {
+		t.Fatalf("file exporter should write spans to file,got %s", body)
+	}
+}